@@ -0,0 +1,133 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func newBenchLogger(level int) *Logger {
+	return NewLogger(level).SetOptions("caller", false, "format", "[%D %T] [%L] %M%F").SetOutput(ioutil.Discard)
+}
+
+func BenchmarkLogDisabled(b *testing.B) {
+	l := newBenchLogger(ERROR)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("message")
+	}
+}
+
+func BenchmarkLogDisabledWithArgs(b *testing.B) {
+	l := newBenchLogger(ERROR)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("message %d", 1)
+	}
+}
+
+// BenchmarkEntryDisabled allocates the entry before the level is checked.
+// See BenchmarkEntryReusedDisabled.
+func BenchmarkEntryDisabled(b *testing.B) {
+	l := newBenchLogger(ERROR)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.With("k", "v").Info("message")
+	}
+}
+
+func BenchmarkEntryReusedDisabled(b *testing.B) {
+	e := newBenchLogger(ERROR).With("k", "v")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e.Info("message")
+	}
+}
+
+func BenchmarkLogEnabled(b *testing.B) {
+	l := newBenchLogger(INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("message")
+	}
+}
+
+func BenchmarkLogEnabledWithCaller(b *testing.B) {
+	l := newBenchLogger(INFO).SetOptions("caller", true, "format", "[%D %T] [%L] (%S:%N) %M")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("message")
+	}
+}
+
+func BenchmarkLogEnabledCallerNotNeeded(b *testing.B) {
+	l := newBenchLogger(INFO).SetOptions("caller", true)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("message")
+	}
+}
+
+func BenchmarkLogEnabledWithArgs(b *testing.B) {
+	l := newBenchLogger(INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("message %d", i)
+	}
+}
+
+func BenchmarkEntryEnabledWithFields(b *testing.B) {
+	l := newBenchLogger(INFO)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.WithFields(String("k", "v"), Int64("n", 1)).Info("message")
+	}
+}
+
+func BenchmarkLogEnabledFilter(b *testing.B) {
+	l := newBenchLogger(INFO).SetOutput(nil)
+	l.AddFilter("app", INFO, &queueApp{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("message")
+	}
+}
+
+func BenchmarkLogDisabledFilter(b *testing.B) {
+	l := newBenchLogger(INFO).SetOutput(nil)
+	l.AddFilter("app", WARN, &queueApp{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("message")
+	}
+}
+
+func TestLogAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not stable with the race detector")
+	}
+
+	tests := []struct {
+		name  string
+		level int
+		max   float64
+	}{
+		{"disabled", ERROR, 0},
+		{"enabled", INFO, 1},
+	}
+	for _, tt := range tests {
+		l := newBenchLogger(tt.level)
+		l.Info("message") // warm up
+		if n := testing.AllocsPerRun(100, func() { l.Info("message") }); n > tt.max {
+			t.Errorf("%s: %v allocations, want <= %v", tt.name, n, tt.max)
+		}
+	}
+
+	// An entry is allocated before the level is known, so only a reused
+	// entry rejects disabled levels without allocation
+	e := newBenchLogger(ERROR).With("k", "v")
+	if n := testing.AllocsPerRun(100, func() { e.Info("message") }); n > 0 {
+		t.Errorf("disabled entry: %v allocations, want 0", n)
+	}
+}
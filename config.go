@@ -3,8 +3,10 @@
 package nxlog4go
 
 import (
+	"encoding/xml"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ccpaging/nxlog4go/cast"
 	"github.com/ccpaging/nxlog4go/driver"
@@ -16,6 +18,15 @@ type NameValue struct {
 	Value string `xml:",chardata" json:"value"`
 }
 
+// ProcessorConfig declares a processor by its registered type and options,
+// such as
+//  <processor type="env"><property name="pod">POD_NAME</property></processor>
+// See driver.OpenProcessor.
+type ProcessorConfig struct {
+	Type       string      `xml:"type,attr" json:"type"`
+	Properties []NameValue `xml:"property" json:"properties,omitempty"`
+}
+
 // FilterConfig offers a declarative way to construct a logger's default writer,
 // internal log and 3rd appenders
 type FilterConfig struct {
@@ -25,13 +36,71 @@ type FilterConfig struct {
 	Dsn        string      `xml:"dsn" json:"dsn"`
 	Level      string      `xml:"level" json:"level"`
 	Properties []NameValue `xml:"property" json:"properties"`
+
+	Processors []*ProcessorConfig `xml:"processor" json:"processors,omitempty"`
+
+	unresolved bool // Dsn or Level fails to expand. See expand
 }
 
 // LoggerConfig offers a declarative way to construct a logger.
 // See examples/config.xml and examples/config.json for documentation
+//
+// Loggers sets the levels of named loggers, such as
+//  <logger name="db.pool">DEBUG</logger>
+// An empty level restores inheritance from the parent.
+//
+// Processors are called before dispatching to all filters, while the
+// processors of a filter are called for the filter only.
 type LoggerConfig struct {
-	Version string          `xml:"version,attr" json:"version"`
-	Filters []*FilterConfig `xml:"filter" json:"filters"`
+	XMLName    xml.Name           `xml:"logging" json:"-"`
+	Version    string             `xml:"version,attr" json:"version"`
+	Filters    []*FilterConfig    `xml:"filter" json:"filters"`
+	Loggers    []NameValue        `xml:"logger" json:"loggers,omitempty"`
+	Processors []*ProcessorConfig `xml:"processor" json:"processors,omitempty"`
+}
+
+// loadProcessors opens the declared processors. Processors which can not
+// be opened are skipped.
+func loadProcessors(where string, pcs []*ProcessorConfig) (ps driver.Processors, errs []error) {
+	for i, pc := range pcs {
+		if pc == nil {
+			continue
+		}
+		if pc.Type == "" {
+			errs = append(errs, fmt.Errorf("Warn: The type of %s processor [%d] is not defined", where, i))
+			continue
+		}
+		var args []interface{}
+		for _, prop := range pc.Properties {
+			args = append(args, prop.Name, strings.Trim(prop.Value, " \r\n"))
+		}
+		p, err := driver.OpenProcessor(pc.Type, args...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Warn: Open %s processor [%s]. %v", where, pc.Type, err))
+			continue
+		}
+		ps = append(ps, p)
+	}
+	return
+}
+
+func setNamedLevels(l *Logger, loggers []NameValue) (errs []error) {
+	for _, nv := range loggers {
+		if strings.Trim(nv.Name, ". ") == "" {
+			errs = append(errs, fmt.Errorf("Warn: The name of logger is not defined"))
+			continue
+		}
+		named := l.Named(nv.Name)
+		v := strings.Trim(nv.Value, " \r\n")
+		if v == "" {
+			named.ResetLevel()
+			continue
+		}
+		if err := named.Set("level", v); err != nil {
+			errs = append(errs, fmt.Errorf("Warn: Set logger [%s] level as [%s]. %s", nv.Name, v, err.Error()))
+		}
+	}
+	return
 }
 
 func setLogger(l *Logger, enb bool, fc *FilterConfig) (errs []error) {
@@ -46,35 +115,103 @@ func setLogger(l *Logger, enb bool, fc *FilterConfig) (errs []error) {
 	return
 }
 
-func loadFilter(fc *FilterConfig) (filter *driver.Filter, errs []error) {
-	app, err := driver.Open(fc.Type, fc.Dsn)
-	if app == nil {
-		return nil, append(errs, err)
+// Default sampling options of a filter.
+var (
+	DefaultSampleTick       = time.Second
+	DefaultSampleFirst      = 100
+	DefaultSampleThereafter = 100
+)
+
+// setFilterOption sets the filter level options, which are shared by all
+// appenders of the filter:
+//  sampleTick, sampleFirst, sampleThereafter, sampleBy - See driver.Sampler
+//  suppressWindow - See driver.Suppressor
+//
+// Return false if the option is not a filter option.
+func setFilterOption(f *driver.Filter, k string, v string) (bool, error) {
+	if strings.HasPrefix(k, "sample") {
+		if s, ok := f.Enabler.(*driver.Sampler); ok {
+			return true, s.Set(k, v)
+		}
+		// Wrap the Enabler only if the option is valid
+		s := driver.NewSampler(f.Enabler, DefaultSampleTick, DefaultSampleFirst, DefaultSampleThereafter)
+		if err := s.Set(k, v); err != nil {
+			return true, err
+		}
+		f.Enabler = s
+		return true, nil
+	}
+	if strings.HasPrefix(k, "suppress") {
+		if f.Suppressor == nil {
+			f.Suppressor = driver.NewSuppressor(0)
+		}
+		return true, f.Suppressor.Set(k, v)
 	}
+	return false, nil
+}
 
-	for _, prop := range fc.Properties {
-		v := strings.Trim(prop.Value, " \r\n")
+// setFilterProperty sets the property as filter option, or as option of
+// all appenders of the filter.
+func setFilterProperty(f *driver.Filter, fc *FilterConfig, prop NameValue) error {
+	v := strings.Trim(prop.Value, " \r\n")
+	if ok, err := setFilterOption(f, prop.Name, v); ok {
+		if err != nil {
+			return fmt.Errorf("Warn: Set filter [%s] as [%s=%s]. %s", fc.Tag, prop.Name, v, err.Error())
+		}
+		return nil
+	}
+	for _, app := range f.Apps {
+		if app == nil {
+			continue
+		}
 		if err := app.Set(prop.Name, v); err != nil {
-			errs = append(errs, fmt.Errorf("Warn: Set [%s] as [%s=%s]. %s", fc.Type, prop.Name, v, err.Error()))
+			return fmt.Errorf("Warn: Set [%s] as [%s=%s]. %s", fc.Type, prop.Name, v, err.Error())
 		}
 	}
+	return nil
+}
+
+func loadFilter(fc *FilterConfig) (filter *driver.Filter, errs []error) {
+	app, err := driver.Open(fc.Type, fc.Dsn)
+	if app == nil {
+		return nil, append(errs, err)
+	}
 
 	filter = &driver.Filter{
 		Name:    fc.Tag,
-		Enabler: driver.AtAbove(Level(INFO).Int(fc.Level)),
+		Enabler: driver.NewAtomicLevel(Level(INFO).Int(fc.Level)),
 		Layout:  nil,
 		Apps:    []driver.Appender{app},
 	}
 
+	for _, prop := range fc.Properties {
+		if err := setFilterProperty(filter, fc, prop); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var e []error
+	filter.Processors, e = loadProcessors("filter ["+fc.Tag+"]", fc.Processors)
+	errs = append(errs, e...)
+
 	errs = append(errs, fmt.Errorf("Trace: Succeeded loading tag [%s], type [%s], dsn [%s]", fc.Tag, fc.Type, fc.Dsn))
 	return
 }
 
 // LoadConfiguration sets options of logger, and creates/loads/sets appenders.
+//
+// Dsn, Level and property values are expanded before loading:
+//  ${VAR}, ${VAR:-default} - The environment variable, or default if it is
+//                            unset or empty. Unresolved variables are reported,
+//                            and the property, or the filter if in Dsn or
+//                            Level, is skipped
+//  @file:/path             - The content of the file, such as a secret
 func (l *Logger) LoadConfiguration(lc *LoggerConfig) (errs []error) {
 	if lc == nil {
 		return append(errs, fmt.Errorf("Warn: Logger configuration is NIL"))
 	}
+	raw := lc.clone()
+	lc, errs = lc.expand()
 
 	var filters []*driver.Filter
 	for i, fc := range lc.Filters {
@@ -86,6 +223,9 @@ func (l *Logger) LoadConfiguration(lc *LoggerConfig) (errs []error) {
 			fc.Tag = fc.Type
 
 		}
+		if fc.unresolved {
+			continue
+		}
 
 		enabled, err := cast.ToBool(fc.Enabled)
 		if err != nil {
@@ -112,5 +252,17 @@ func (l *Logger) LoadConfiguration(lc *LoggerConfig) (errs []error) {
 	}
 
 	l.Attach(filters...)
+	errs = append(errs, setNamedLevels(l, lc.Loggers)...)
+
+	if len(lc.Processors) > 0 {
+		ps, e := loadProcessors("logger", lc.Processors)
+		errs = append(errs, e...)
+		l.SetProcessors(ps...)
+	}
+
+	// Keep the configuration unexpanded, without secrets
+	l.mu.Lock()
+	l.config = raw
+	l.mu.Unlock()
 	return
 }
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	l4g "github.com/ccpaging/nxlog4go"
 	_ "github.com/ccpaging/nxlog4go/console"
+	"github.com/ccpaging/nxlog4go/driver"
 	_ "github.com/ccpaging/nxlog4go/file"
 	_ "github.com/ccpaging/nxlog4go/socket"
 	"io/ioutil"
@@ -35,6 +36,7 @@ var xmlBuf = `<logging>
     <!-- level is (:?FINEST|FINE|DEBUG|TRACE|INFO|WARN|ERROR) -->
     <level>DEBUG</level>
     <property name="color">true</property>
+    <property name="suppressWindow">30s</property> <!-- collapses duplicate messages -->
   </filter>
   <filter enabled="true">
     <tag>file</tag>
@@ -154,3 +156,82 @@ func TestXMLConfig(t *testing.T) {
 	fmt.Fprint(fd, string(jsonBuf))
 	fd.Close()
 }
+
+func TestSampleConfig(t *testing.T) {
+	lc := &l4g.LoggerConfig{
+		Filters: []*l4g.FilterConfig{{
+			Enabled: "true",
+			Tag:     "sampled",
+			Type:    "console",
+			Level:   "INFO",
+			Properties: []l4g.NameValue{
+				{Name: "sampleFirst", Value: "10"},
+				{Name: "sampleThereafter", Value: "0"},
+			},
+		}},
+	}
+
+	log := l4g.NewLogger(l4g.DEBUG)
+	log.LoadConfiguration(lc)
+	defer log.Close()
+
+	f := log.Filters()["sampled"]
+	if f == nil {
+		t.Fatalf("Missing filter %q", "sampled")
+	}
+	s, ok := f.Enabler.(*driver.Sampler)
+	if !ok {
+		t.Fatalf("Expected sampler, found %T", f.Enabler)
+	}
+	if s.Enabled(&driver.Recorder{Level: l4g.DEBUG}) {
+		t.Errorf("Sampler should keep the filter level")
+	}
+}
+
+func TestSampleConfigInvalid(t *testing.T) {
+	for _, prop := range []l4g.NameValue{
+		{Name: "sampleEvery", Value: "10"},
+		{Name: "sampleTick", Value: "0s"},
+	} {
+		lc := &l4g.LoggerConfig{
+			Filters: []*l4g.FilterConfig{{
+				Enabled:    "true",
+				Tag:        "sampled",
+				Type:       "console",
+				Level:      "INFO",
+				Properties: []l4g.NameValue{prop},
+			}},
+		}
+
+		log := l4g.NewLogger(l4g.DEBUG)
+		if errs := log.LoadConfiguration(lc); len(errs) == 0 {
+			t.Errorf("%s=%s should fail", prop.Name, prop.Value)
+		}
+		if f := log.Filters()["sampled"]; f == nil {
+			t.Errorf("Missing filter %q", "sampled")
+		} else if _, ok := f.Enabler.(*driver.Sampler); ok {
+			t.Errorf("%s=%s should not wrap the Enabler in a sampler", prop.Name, prop.Value)
+		}
+		log.Close()
+	}
+}
+
+func TestNamedLevelsConfig(t *testing.T) {
+	lc := new(l4g.LoggerConfig)
+	err := xml.Unmarshal([]byte(`<logging>
+  <logger name="db">ERROR</logger>
+  <logger name="db.pool">DEBUG</logger>
+</logging>`), lc)
+	if err != nil {
+		t.Fatalf("Could not parse XML configuration: %s", err)
+	}
+
+	log := l4g.NewLogger(l4g.INFO)
+	log.LoadConfiguration(lc)
+
+	for name, want := range map[string]int{"db": l4g.ERROR, "db.pool": l4g.DEBUG, "db.pool.conn": l4g.DEBUG, "web": l4g.INFO} {
+		if got := log.Named(name).Level(); got != want {
+			t.Errorf("%s: got %d, want %d", name, got, want)
+		}
+	}
+}
@@ -16,6 +16,8 @@ import (
 
 // ColorBytes represents ANSI code to set different color of levels
 // 0, Black; 1, Red; 2, Green; 3, Yellow; 4, Blue; 5, Purple; 6, Cyan; 7, White
+//
+// User-defined levels use the color of registration. See l4g.RegisterLevel.
 var ColorBytes = [...][]byte{
 	[]byte("\033[30;1m"), // FINEST, Gray
 	[]byte("\033[32m"),   // FINE, Green
@@ -33,13 +35,18 @@ var ColorReset = []byte("\x1b[0m")
 // Appender is an Appender with ANSI color that prints to stderr.
 // Support ANSI term includes ConEmu for windows.
 type Appender struct {
-	mu       sync.Mutex            // ensures atomic writes; protects the following fields
-	rec      chan *driver.Recorder // entry channel
+	stats driver.StatsCounter // bytes written and write errors; first for alignment
+
+	mu       sync.Mutex         // ensures atomic writes; protects the following fields
+	queue    *driver.Queue      // entry queue
+	flush    chan chan struct{} // flush request channel
+	exit     chan struct{}      // closed when run returns
 	runOnce  sync.Once
 	waitExit *sync.WaitGroup
 
-	level  int
+	level  driver.AtomicLevel
 	layout driver.Layout // format entry for output
+	errs   driver.ErrorReporter
 
 	out   io.Writer // destination for output
 	color bool
@@ -61,7 +68,8 @@ func init() {
 // NewAppender creates the appender output to os.Stderr.
 func NewAppender(w io.Writer, args ...interface{}) *Appender {
 	ca := &Appender{
-		rec: make(chan *driver.Recorder, 32),
+		queue: driver.NewQueue(driver.DefaultQueueCapacity, driver.OverflowBlock),
+		flush: make(chan chan struct{}),
 
 		layout: patt.NewLayout(""),
 
@@ -77,6 +85,12 @@ func (*Appender) Open(dsn string, args ...interface{}) (driver.Appender, error)
 	return NewAppender(os.Stderr, args...), nil
 }
 
+// Validate checks the name-value option on a new appender. See
+// driver.Validator.
+func (*Appender) Validate(k string, v interface{}) error {
+	return NewAppender(os.Stderr).Set(k, v)
+}
+
 // Writer returns the output destination for the appender.
 func (ca *Appender) Writer() io.Writer {
 	ca.mu.Lock()
@@ -104,9 +118,48 @@ func (ca *Appender) SetLayout(layout driver.Layout) *Appender {
 	ca.mu.Lock()
 	defer ca.mu.Unlock()
 	ca.layout = layout
+	driver.InvalidateLevels()
 	return ca
 }
 
+// Needs returns the attributes used by the layout. See driver.AttrsNeeder.
+func (ca *Appender) Needs() driver.Attrs {
+	return driver.NeedsOf(ca.Layout())
+}
+
+// Level returns the output level of the appender.
+func (ca *Appender) Level() int {
+	return ca.level.Level()
+}
+
+// SetLevel sets the output level of the appender. It is safe while logging.
+func (ca *Appender) SetLevel(n int) {
+	ca.level.SetLevel(n)
+}
+
+// SetErrorHandler sets the handler of write errors.
+// See driver.ErrorHandlerSetter.
+func (ca *Appender) SetErrorHandler(name string, h driver.ErrorHandler) {
+	ca.errs.SetErrorHandler(name, h)
+}
+
+// Options returns the current name-value pair options of the appender
+// and its layout. See Set.
+func (ca *Appender) Options() []interface{} {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+
+	opts := []interface{}{
+		"level", l4g.Level(ca.level.Level()).Name(),
+		"color", ca.color,
+	}
+	opts = append(opts, ca.queue.Options()...)
+	if o, ok := ca.layout.(driver.Optioner); ok {
+		opts = append(opts, o.Options()...)
+	}
+	return opts
+}
+
 // SetOptions sets name-value pair options.
 //
 // Return the appender.
@@ -120,14 +173,18 @@ func (ca *Appender) SetOptions(args ...interface{}) *Appender {
 
 // Enabled encodes log Recorder and output it.
 func (ca *Appender) Enabled(r *driver.Recorder) bool {
-	if r.Level < ca.level {
+	if driver.LevelBelow(r.Level, ca.level.Level()) {
 		return false
 	}
 
 	ca.runOnce.Do(func() {
 		ca.waitExit = &sync.WaitGroup{}
 		ca.waitExit.Add(1)
-		go ca.run(ca.waitExit)
+		exit := make(chan struct{})
+		ca.mu.Lock()
+		ca.exit = exit
+		ca.mu.Unlock()
+		go ca.run(ca.waitExit, exit)
 	})
 
 	// Write after closed
@@ -136,7 +193,7 @@ func (ca *Appender) Enabled(r *driver.Recorder) bool {
 		return false
 	}
 
-	ca.rec <- r
+	ca.queue.Put(r.Clone())
 	return false
 }
 
@@ -146,28 +203,85 @@ func (ca *Appender) Write(b []byte) (int, error) {
 	return 0, nil
 }
 
-func (ca *Appender) run(waitExit *sync.WaitGroup) {
+func (ca *Appender) drain() {
 	for {
 		select {
-		case r, ok := <-ca.rec:
+		case r, ok := <-ca.queue.C():
+			if !ok {
+				return
+			}
+			ca.output(r)
+			r.Release()
+		default:
+			return
+		}
+	}
+}
+
+// Pending returns the number of queued log recorders. See driver.Pending.
+func (ca *Appender) Pending() int {
+	return ca.queue.Len()
+}
+
+// Dropped returns the number of log recorders dropped when the queue
+// overflows. See driver.Dropper.
+func (ca *Appender) Dropped() uint64 {
+	return ca.queue.Dropped()
+}
+
+// Stats returns the output statistics. See driver.Statser.
+func (ca *Appender) Stats() driver.Stats {
+	return ca.stats.Stats()
+}
+
+// Flush writes all queued log recorders. It blocks until done, or until
+// the appender is closed.
+func (ca *Appender) Flush() {
+	ca.mu.Lock()
+	exit := ca.exit
+	ca.mu.Unlock()
+
+	if exit != nil {
+		done := make(chan struct{})
+		select {
+		case ca.flush <- done:
+			<-done
+		case <-exit:
+			// Closed already
+		}
+	}
+}
+
+func (ca *Appender) run(waitExit *sync.WaitGroup, exit chan struct{}) {
+	defer close(exit)
+
+	for {
+		select {
+		case done := <-ca.flush:
+			ca.drain()
+			close(done)
+
+		case r, ok := <-ca.queue.C():
 			if !ok {
 				waitExit.Done()
 				return
 			}
 			ca.output(r)
+			r.Release()
 		}
 	}
 }
 
 func (ca *Appender) closeChannel() {
 	// notify closing. See run()
-	close(ca.rec)
+	ca.queue.Close()
 	// waiting for running channel closed
 	ca.waitExit.Wait()
 	ca.waitExit = nil
 	// drain channel
-	for r := range ca.rec {
+	for r := range ca.queue.C() {
 		ca.output(r)
+		r.Release()
 	}
 }
 
@@ -184,11 +298,12 @@ func (ca *Appender) output(r *driver.Recorder) {
 	defer ca.mu.Unlock()
 
 	if ca.color {
-		level := r.Level
-		if level >= len(ColorBytes) {
-			level = l4g.INFO
+		if level := r.Level; level >= 0 && level < len(ColorBytes) {
+			ca.out.Write(ColorBytes[level])
+		} else {
+			// user-defined level
+			ca.out.Write(l4g.Level(level).Color().Bytes())
 		}
-		ca.out.Write(ColorBytes[level])
 	}
 
 	buf := bufferPool.Get().(*bytes.Buffer)
@@ -196,7 +311,11 @@ func (ca *Appender) output(r *driver.Recorder) {
 	defer bufferPool.Put(buf)
 
 	ca.layout.Encode(buf, r)
-	ca.out.Write(buf.Bytes())
+	n, err := ca.out.Write(buf.Bytes())
+	ca.stats.Wrote(n, err)
+	if err != nil {
+		ca.errs.Report("write", err)
+	}
 
 	if ca.color {
 		ca.out.Write(ColorReset)
@@ -205,6 +324,9 @@ func (ca *Appender) output(r *driver.Recorder) {
 
 // Set sets name-value option with:
 //  level    - The output level
+//  queue    - The capacity of the queue. The default is 32
+//  overflow - The overflow policy of the queue. "block" (default),
+//             "dropNewest", "dropOldest" or "timeout". See driver.Queue
 //	color    - Force to color or not
 //
 // Pattern layout options (The default is JSON):
@@ -220,13 +342,15 @@ func (ca *Appender) Set(k string, v interface{}) (err error) {
 	case "level":
 		var n int
 		if n, err = l4g.Level(l4g.INFO).IntE(v); err == nil {
-			ca.level = n
+			ca.level.SetLevel(n)
 		}
 	case "color":
 		var color bool
 		if color, err = cast.ToBool(v); err == nil {
 			ca.color = color
 		}
+	case "queue", "overflow", "overflowTimeout":
+		err = ca.queue.Set(k, v)
 	default:
 		return ca.layout.Set(k, v)
 	}
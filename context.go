@@ -0,0 +1,95 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"context"
+	"sync"
+)
+
+// ContextExtractor pulls values out of a context, such as request IDs,
+// tenant or trace and span IDs. It returns name-value pairs which are
+// appended to the log recorder's values at log time.
+type ContextExtractor func(ctx context.Context) []interface{}
+
+var (
+	extractorMu    sync.RWMutex
+	extractors     = make(map[string]ContextExtractor)
+	extractorNames []string
+)
+
+// RegisterContextExtractor registers the named context extractor.
+// Extractors run in the order they were first registered.
+// Registering a nil extractor removes the named one.
+func RegisterContextExtractor(name string, fn ContextExtractor) {
+	if name == "" {
+		return
+	}
+
+	extractorMu.Lock()
+	defer extractorMu.Unlock()
+
+	_, ok := extractors[name]
+	if fn == nil {
+		if ok {
+			delete(extractors, name)
+			for i, n := range extractorNames {
+				if n == name {
+					extractorNames = append(extractorNames[:i:i], extractorNames[i+1:]...)
+					break
+				}
+			}
+		}
+		return
+	}
+	if !ok {
+		extractorNames = append(extractorNames, name)
+	}
+	extractors[name] = fn
+}
+
+// contextValues runs all registered extractors against the context.
+func contextValues(ctx context.Context) (values []interface{}) {
+	if ctx == nil {
+		return
+	}
+
+	// Extractors are called without the lock, so they may register
+	// extractors themselves
+	extractorMu.RLock()
+	fns := make([]ContextExtractor, 0, len(extractorNames))
+	for _, name := range extractorNames {
+		fns = append(fns, extractors[name])
+	}
+	extractorMu.RUnlock()
+
+	for _, fn := range fns {
+		values = append(values, fn(ctx)...)
+	}
+	return
+}
+
+type entryKey struct{}
+
+// NewContext returns a copy of the parent context which carries the entry.
+func NewContext(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, e)
+}
+
+// FromContext returns a copy of the entry carried by the context, or a new
+// entry of the standard logger if there is none. The entry extracts values
+// from the context at log time. See Ctx.
+func FromContext(ctx context.Context) *Entry {
+	if ctx != nil {
+		if e, ok := ctx.Value(entryKey{}).(*Entry); ok && e != nil {
+			return e.clone().WithContext(ctx)
+		}
+	}
+	return NewEntry(std).WithContext(ctx)
+}
+
+// Ctx creates a logging entry which extracts values from the context
+// at log time.
+func (l *Logger) Ctx(ctx context.Context) *Entry {
+	return NewEntry(l).WithContext(ctx)
+}
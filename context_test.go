@@ -0,0 +1,104 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+type requestIDKey struct{}
+
+func TestLoggerCtx(t *testing.T) {
+	RegisterContextExtractor("reqid", func(ctx context.Context) []interface{} {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []interface{}{"reqid", id}
+		}
+		return nil
+	})
+	defer RegisterContextExtractor("reqid", nil)
+
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%L %M%F").SetOutput(buf)
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "42")
+	l.Ctx(ctx).With("k", "v").Info("hello")
+	if got, want := buf.String(), "INFO hello k=v reqid=42\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	buf.Reset()
+	l.Ctx(context.Background()).Info("hello")
+	if got, want := buf.String(), "INFO hello\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%P %M").SetOutput(buf)
+
+	e := NewEntry(l).SetPrefix("carried")
+	ctx := NewContext(context.Background(), e)
+	if got := FromContext(ctx); got == e || got.ctx != ctx {
+		t.Errorf("FromContext should return a copy of the carried entry with the context")
+	}
+
+	FromContext(ctx).Info("message")
+	if got, want := buf.String(), "carried message\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	// Values of the context are extracted, and fields of the copy don't
+	// affect the carried entry
+	RegisterContextExtractor("reqid", func(ctx context.Context) []interface{} {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []interface{}{"reqid", id}
+		}
+		return nil
+	})
+	defer RegisterContextExtractor("reqid", nil)
+	l.SetOptions("format", "%P %M%F")
+
+	buf.Reset()
+	FromContext(context.WithValue(ctx, requestIDKey{}, "42")).With("k", "v").Info("message")
+	FromContext(ctx).Info("message")
+	if got, want := buf.String(), "carried message k=v reqid=42\ncarried message\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	if FromContext(context.Background()).log != std {
+		t.Errorf("FromContext should fall back to the standard logger")
+	}
+}
+
+func TestContextExtractorRegisters(t *testing.T) {
+	// An extractor which registers extractors must not deadlock
+	RegisterContextExtractor("lazy", func(ctx context.Context) []interface{} {
+		RegisterContextExtractor("other", func(ctx context.Context) []interface{} {
+			return []interface{}{"other", 1}
+		})
+		return []interface{}{"lazy", 1}
+	})
+	defer RegisterContextExtractor("lazy", nil)
+	defer RegisterContextExtractor("other", nil)
+
+	done := make(chan []interface{})
+	go func() {
+		done <- contextValues(context.Background())
+	}()
+	select {
+	case values := <-done:
+		if len(values) != 2 || values[0] != "lazy" {
+			t.Errorf("Unexpected values %v", values)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("contextValues deadlocked")
+	}
+}
@@ -2,6 +2,7 @@ package driver
 
 import (
 	"errors"
+	"reflect"
 )
 
 // Appender is an interface for anything that should be able to write logs
@@ -16,7 +17,8 @@ type Appender interface {
 	// and written bytes after.
 	//
 	// Enabled can write the recorder directly with owner format,
-	// and then return false.
+	// and then return false. Asynchronous appenders should queue a Clone
+	// of the recorder, which is borrowed. See Recorder.
 	Enabled(*Recorder) bool
 
 	// Write will be called to write the log bytes.
@@ -27,6 +29,24 @@ type Appender interface {
 	Close()
 }
 
+// Flusher is implemented by appenders which write asynchronously or buffer
+// output. Flush writes all queued log recorders and flushes buffers, and
+// blocks until done.
+type Flusher interface {
+	Flush()
+}
+
+// Validator is implemented by appenders which check a name-value option
+// without opening an appender, which may create files or connect. See
+// Validate.
+type Validator interface {
+	Validate(name string, value interface{}) error
+}
+
+// ErrNotValidated is returned by Validate if the appender does not
+// implement Validator.
+var ErrNotValidated = errors.New("options are not validated")
+
 type nopAppender struct{}
 
 // NewNopAppender returns a no-op Layout.
@@ -63,3 +83,39 @@ func Open(name string, dsn string, args ...interface{}) (Appender, error) {
 	}
 	return nil, errors.New("Not register " + name)
 }
+
+// Registered returns true if the named appender is registered.
+func Registered(name string) bool {
+	_, ok := registered[name]
+	return ok
+}
+
+// Validate checks the name-value option of the named appender by its
+// Validator, without opening the appender.
+//
+// Return ErrNotValidated if the appender does not implement Validator.
+func Validate(name string, k string, v interface{}) error {
+	app, ok := registered[name]
+	if !ok {
+		return errors.New("Not register " + name)
+	}
+	if val, ok := app.(Validator); ok {
+		return val.Validate(k, v)
+	}
+	return ErrNotValidated
+}
+
+// NameOf returns the registered name of the appender's type.
+// Return "" if the type is not registered.
+func NameOf(app Appender) string {
+	if app == nil {
+		return ""
+	}
+	t := reflect.TypeOf(app)
+	for name, a := range registered {
+		if reflect.TypeOf(a) == t {
+			return name
+		}
+	}
+	return ""
+}
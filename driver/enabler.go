@@ -31,6 +31,20 @@ func RangeLevel(min, max int) Enabler { return &rangeLevel{} }
 
 func (e *denyAll) Enabled(r *Recorder) bool    { return false }
 func (e *acceptAll) Enabled(r *Recorder) bool  { return true }
-func (e *atAbove) Enabled(r *Recorder) bool    { return (r.Level >= e.atAbove) }
+func (e *atAbove) Enabled(r *Recorder) bool    { return !LevelBelow(r.Level, e.atAbove) }
 func (e *matchLevel) Enabled(r *Recorder) bool { return (r.Level == e.match) }
-func (e *rangeLevel) Enabled(r *Recorder) bool { return (r.Level >= e.min && r.Level <= e.max) }
+func (e *rangeLevel) Enabled(r *Recorder) bool {
+	return !(LevelBelow(r.Level, e.min) || LevelBelow(e.max, r.Level))
+}
+
+func (e *denyAll) MinLevel() int    { return MinLevelNone }
+func (e *acceptAll) MinLevel() int  { return MinLevelAll }
+func (e *atAbove) MinLevel() int    { return e.atAbove }
+func (e *matchLevel) MinLevel() int { return e.match }
+func (e *rangeLevel) MinLevel() int { return e.min }
+
+func (e *denyAll) Needs() Attrs    { return 0 }
+func (e *acceptAll) Needs() Attrs  { return 0 }
+func (e *atAbove) Needs() Attrs    { return 0 }
+func (e *matchLevel) Needs() Attrs { return 0 }
+func (e *rangeLevel) Needs() Attrs { return 0 }
@@ -0,0 +1,214 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorHandler is an interface for anything that handles the errors of
+// appenders, such as failing to dial a socket, rename or write a file.
+// Log recorders are usually lost when an error occurs.
+//
+// HandleError may be called concurrently from the goroutines of
+// asynchronous appenders, and should not log through the same logger.
+type ErrorHandler interface {
+	// HandleError handles the error of the appender's operation, such as
+	// "write", "dial", "rotate".
+	HandleError(appender string, op string, err error)
+}
+
+// ErrorHandlerFunc is a function which implements ErrorHandler.
+type ErrorHandlerFunc func(appender string, op string, err error)
+
+// HandleError calls fn(appender, op, err).
+func (fn ErrorHandlerFunc) HandleError(appender string, op string, err error) {
+	fn(appender, op, err)
+}
+
+// ErrorHandlerSetter is implemented by appenders which report errors by
+// themselves, such as the asynchronous appenders. The name is used as the
+// appender name of the reported errors.
+type ErrorHandlerSetter interface {
+	SetErrorHandler(name string, h ErrorHandler)
+}
+
+// ErrorReporter reports errors to the handler set. It is safe for
+// concurrent use. The zero value reports nothing.
+type ErrorReporter struct {
+	v atomic.Value // *namedHandler
+}
+
+type namedHandler struct {
+	name string
+	h    ErrorHandler
+}
+
+// SetErrorHandler sets the name and the handler. A nil handler disables
+// reporting.
+func (e *ErrorReporter) SetErrorHandler(name string, h ErrorHandler) {
+	e.v.Store(&namedHandler{name: name, h: h})
+}
+
+// Report reports the error of the operation to the handler.
+// Return false if there is no handler.
+func (e *ErrorReporter) Report(op string, err error) bool {
+	nh, _ := e.v.Load().(*namedHandler)
+	if nh == nil || nh.h == nil {
+		return false
+	}
+	nh.h.HandleError(nh.name, op, err)
+	return true
+}
+
+/** Built-in error handlers **/
+
+// WriterErrorHandler writes errors to the writer, one error per line, as
+//
+//	nxlog4go: <appender> <op>: <error>
+type WriterErrorHandler struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewWriterErrorHandler creates an error handler which writes to w.
+func NewWriterErrorHandler(w io.Writer) *WriterErrorHandler {
+	return &WriterErrorHandler{out: w}
+}
+
+// NewStderrErrorHandler creates an error handler which writes to os.Stderr.
+func NewStderrErrorHandler() *WriterErrorHandler {
+	return NewWriterErrorHandler(os.Stderr)
+}
+
+// HandleError writes the error.
+func (h *WriterErrorHandler) HandleError(appender string, op string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(h.out, "nxlog4go: %s %s: %v\n", appender, op, err)
+}
+
+// CountingErrorHandler counts errors by appender and operation, then
+// passes them to the next handler, if it is not nil.
+type CountingErrorHandler struct {
+	next ErrorHandler
+
+	total  uint64
+	mu     sync.Mutex
+	counts map[[2]string]uint64
+}
+
+// NewCountingErrorHandler creates a counting error handler. The next
+// handler may be nil.
+func NewCountingErrorHandler(next ErrorHandler) *CountingErrorHandler {
+	return &CountingErrorHandler{
+		next:   next,
+		counts: make(map[[2]string]uint64),
+	}
+}
+
+// HandleError counts the error.
+func (h *CountingErrorHandler) HandleError(appender string, op string, err error) {
+	atomic.AddUint64(&h.total, 1)
+	h.mu.Lock()
+	h.counts[[2]string{appender, op}]++
+	h.mu.Unlock()
+
+	if h.next != nil {
+		h.next.HandleError(appender, op, err)
+	}
+}
+
+// Total returns the number of all errors.
+func (h *CountingErrorHandler) Total() uint64 {
+	return atomic.LoadUint64(&h.total)
+}
+
+// Count returns the number of errors of the appender's operation.
+// An empty op counts all operations of the appender.
+func (h *CountingErrorHandler) Count(appender string, op string) (n uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if op != "" {
+		return h.counts[[2]string{appender, op}]
+	}
+	for k, c := range h.counts {
+		if k[0] == appender {
+			n += c
+		}
+	}
+	return
+}
+
+// RateLimitedErrorHandler passes at most burst errors of the same
+// appender and operation to the next handler in every interval. The number
+// of dropped errors is reported with the next passed error.
+type RateLimitedErrorHandler struct {
+	next     ErrorHandler
+	interval time.Duration
+	burst    int
+
+	mu      sync.Mutex
+	windows map[[2]string]*rateWindow
+}
+
+type rateWindow struct {
+	start   time.Time
+	n       int
+	dropped int
+}
+
+// NewRateLimitedErrorHandler creates a rate limited error handler.
+// An interval <= 0 means one minute, and a burst <= 0 means 1.
+func NewRateLimitedErrorHandler(next ErrorHandler, interval time.Duration, burst int) *RateLimitedErrorHandler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &RateLimitedErrorHandler{
+		next:     next,
+		interval: interval,
+		burst:    burst,
+		windows:  make(map[[2]string]*rateWindow),
+	}
+}
+
+// HandleError passes the error to the next handler, or drops it.
+func (h *RateLimitedErrorHandler) HandleError(appender string, op string, err error) {
+	if h.next == nil {
+		return
+	}
+
+	now := time.Now()
+	k := [2]string{appender, op}
+
+	h.mu.Lock()
+	w := h.windows[k]
+	if w == nil {
+		w = &rateWindow{start: now}
+		h.windows[k] = w
+	} else if now.Sub(w.start) >= h.interval {
+		w.start, w.n = now, 0
+	}
+	if w.n >= h.burst {
+		w.dropped++
+		h.mu.Unlock()
+		return
+	}
+	w.n++
+	dropped := w.dropped
+	w.dropped = 0
+	h.mu.Unlock()
+
+	if dropped > 0 {
+		err = fmt.Errorf("%v (%d similar errors dropped)", err, dropped)
+	}
+	h.next.HandleError(appender, op, err)
+}
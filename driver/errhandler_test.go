@@ -0,0 +1,77 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failAppender struct{ nopAppender }
+
+func (*failAppender) Enabled(*Recorder) bool    { return true }
+func (*failAppender) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestFilterErrorHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	counter := NewCountingErrorHandler(NewWriterErrorHandler(buf))
+
+	f := &Filter{
+		Name:   "file",
+		Layout: NewNopLayout(),
+		Apps:   []Appender{&failAppender{}, &failAppender{}},
+	}
+	f.SetErrorHandler(counter)
+	f.Dispatch(&Recorder{})
+
+	if got, want := counter.Total(), uint64(2); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	if got, want := counter.Count("file.1", "write"), uint64(1); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	if got, want := buf.String(), "nxlog4go: file write: disk full\nnxlog4go: file.1 write: disk full\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestRateLimitedErrorHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	h := NewRateLimitedErrorHandler(NewWriterErrorHandler(buf), 50*time.Millisecond, 2)
+
+	err := errors.New("refused")
+	for i := 0; i < 5; i++ {
+		h.HandleError("socket", "dial", err)
+	}
+	h.HandleError("socket", "write", err)
+	if got, want := strings.Count(buf.String(), "\n"), 3; got != want {
+		t.Errorf("   got %d %q", got, buf.String())
+		t.Errorf("  want %d", want)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	buf.Reset()
+	h.HandleError("socket", "dial", err)
+	if got, want := buf.String(), "nxlog4go: socket dial: refused (3 similar errors dropped)\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestErrorReporter(t *testing.T) {
+	var e ErrorReporter
+	if e.Report("write", errors.New("lost")) {
+		t.Errorf("Zero reporter should report nothing")
+	}
+	counter := NewCountingErrorHandler(nil)
+	e.SetErrorHandler("console", counter)
+	if !e.Report("write", errors.New("lost")) || counter.Count("console", "") != 1 {
+		t.Errorf("The error should be reported")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// FieldType indicates which member of the Field union struct should be used
+// and how it should be encoded.
+type FieldType uint8
+
+// Known field types.
+const (
+	UnknownType FieldType = iota
+	StringType
+	Int64Type
+	DurationType
+	TimeType
+	ErrorType
+	BoolType
+	AnyType
+)
+
+// Field is a typed name-value pair. Field values are stored without
+// boxing, except for Any, and encoded without building a map.
+type Field struct {
+	Key       string
+	Type      FieldType
+	Integer   int64
+	String    string
+	Interface interface{}
+}
+
+// String constructs a field with the given key and string value.
+func String(key string, val string) Field {
+	return Field{Key: key, Type: StringType, String: val}
+}
+
+// Int64 constructs a field with the given key and int64 value.
+func Int64(key string, val int64) Field {
+	return Field{Key: key, Type: Int64Type, Integer: val}
+}
+
+// Duration constructs a field with the given key and time.Duration value.
+func Duration(key string, val time.Duration) Field {
+	return Field{Key: key, Type: DurationType, Integer: int64(val)}
+}
+
+// Time constructs a field with the given key and time.Time value.
+// The time zone is kept as the location pointer, so it is not boxed.
+func Time(key string, val time.Time) Field {
+	if val.IsZero() {
+		return Field{Key: key, Type: TimeType, Integer: math.MinInt64, Interface: val.Location()}
+	}
+	return Field{Key: key, Type: TimeType, Integer: val.UnixNano(), Interface: val.Location()}
+}
+
+// Err constructs a field with the key "error" and the error value.
+// A nil error is encoded as "nil".
+func Err(err error) Field {
+	return Field{Key: "error", Type: ErrorType, Interface: err}
+}
+
+// Bool constructs a field with the given key and bool value.
+func Bool(key string, val bool) Field {
+	var n int64
+	if val {
+		n = 1
+	}
+	return Field{Key: key, Type: BoolType, Integer: n}
+}
+
+// Any constructs a field with the given key and an arbitrary value.
+// The value is boxed, so it is no cheaper than With(key, val).
+func Any(key string, val interface{}) Field {
+	return Field{Key: key, Type: AnyType, Interface: val}
+}
+
+// Value returns the boxed value of the field, as it would be stored in
+// Recorder.Values.
+func (f Field) Value() interface{} {
+	switch f.Type {
+	case StringType:
+		return f.String
+	case Int64Type:
+		return f.Integer
+	case DurationType:
+		return time.Duration(f.Integer)
+	case TimeType:
+		return f.time()
+	case ErrorType:
+		if f.Interface == nil {
+			return "nil"
+		}
+		return f.Interface.(error).Error()
+	case BoolType:
+		return f.Integer == 1
+	case AnyType:
+		switch v := f.Interface.(type) {
+		case error:
+			return v.Error()
+		case func() string:
+			return v()
+		}
+		return f.Interface
+	}
+	return nil
+}
+
+func (f Field) time() time.Time {
+	loc, _ := f.Interface.(*time.Location)
+	if f.Integer == math.MinInt64 {
+		return time.Time{}.In(loc)
+	}
+	if loc == nil {
+		return time.Unix(0, f.Integer)
+	}
+	return time.Unix(0, f.Integer).In(loc)
+}
+
+// AppendText appends the text form of the field value to the bytes.
+func (f Field) AppendText(b []byte) []byte {
+	switch f.Type {
+	case StringType:
+		return append(b, f.String...)
+	case Int64Type:
+		return strconv.AppendInt(b, f.Integer, 10)
+	case DurationType:
+		return append(b, time.Duration(f.Integer).String()...)
+	case TimeType:
+		return f.time().AppendFormat(b, time.RFC3339Nano)
+	case BoolType:
+		if f.Integer == 1 {
+			return append(b, "true"...)
+		}
+		return append(b, "false"...)
+	}
+	v := f.Value()
+	if s, ok := v.(string); ok {
+		return append(b, s...)
+	}
+	return append(b, fmt.Sprint(v)...)
+}
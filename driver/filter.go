@@ -4,43 +4,215 @@ package driver
 
 import (
 	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
 )
 
 // Filter contains:
 //  - Enabler, the Enabler interface for filter log Recorder
 //  - Layout, the Layout interface for encoding log Recorder
 //  - Apps, the slice of the Appender interface
+//  - Suppressor, collapses consecutive duplicate log Recorder. May be nil
+//  - Processors, enrich, rewrite or drop log Recorder. May be nil
+//  - ErrorHandler, handles errors of appenders. May be nil. See SetErrorHandler
+//
+// Dispatch may be called concurrently. Encoding and writing are serialized,
+// while Apps[i].Enabled is not, so asynchronous appenders queue recorders
+// in parallel. Change the fields of a filter in use with Update.
 type Filter struct {
+	records uint64 // log recorders dispatched; first for alignment. See Metrics
+
 	Name string
 	Enabler
 	Layout
-	Apps []Appender
+	Apps         []Appender
+	Suppressor   *Suppressor
+	Processors   Processors
+	ErrorHandler ErrorHandler
+
+	mu  sync.RWMutex // blocks dispatching while updating and closing
+	enc sync.Mutex   // serializes encoding and writing
+}
+
+// Update calls fn while dispatching is blocked, so the fields of the
+// filter can be changed safely. The cached levels of loggers are
+// invalidated. See InvalidateLevels.
+func (f *Filter) Update(fn func(f *Filter)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f)
+	InvalidateLevels()
+}
+
+// View calls fn while the filter is not updated, so the fields of the
+// filter can be read safely. fn must not change the filter. See Update.
+func (f *Filter) View(fn func(f *Filter)) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	fn(f)
+}
+
+// MinLevel returns the minimum level which may pass the filter, which is
+// the minimum level of the Enabler, raised to the lowest level of the
+// appenders. See MinLevel.
+func (f *Filter) MinLevel() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n := MinLevel(f.Enabler)
+	apps := MinLevelNone
+	for _, a := range f.Apps {
+		if a == nil {
+			continue
+		}
+		if m := MinLevel(a); LevelBelow(m, apps) {
+			apps = m
+		}
+	}
+	if apps != MinLevelNone && LevelBelow(n, apps) {
+		n = apps
+	}
+	return n
 }
 
 // Dispatch filters, encodes a log recorder to bytes, and writes it to all appenders.
 //  - Enabler.Enabled, filter log Recorder.
+//  - Processors.Process, process a clone of log Recorder, so other filters
+//    are not affected.
+//  - Suppressor.Suppress, drop duplicate log Recorder.
 //  - Layout.Encode, encode log Recorder to bytes.Buffer.
 //  - Apps[i].Enabled, filter log recorder by appender.
-//  - Apps[i].Write, append with log recorder encoded bytes.
+//  - Apps[i].Write, append with log recorder encoded bytes. Errors are
+//    passed to ErrorHandler.
 func (f *Filter) Dispatch(r *Recorder) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
 	if f.Enabler != nil && !f.Enabler.Enabled(r) {
 		return
 	}
 
-	out := new(bytes.Buffer)
-	encoded := false
-	for _, a := range f.Apps {
-		if a != nil && !a.Enabled(r) {
+	if len(f.Processors) > 0 {
+		r = r.Clone()
+		defer r.Release()
+		if !f.Processors.Process(r) {
+			return
+		}
+	}
+
+	if f.Suppressor != nil {
+		summary, drop := f.Suppressor.suppress(r, f)
+		if summary != nil {
+			f.dispatchSummary(summary)
+		}
+		if drop {
+			return
+		}
+	}
+
+	f.dispatch(r)
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// dispatchSummary processes the summary of the suppressor, which is not
+// borrowed, then dispatches it. The filter must be locked.
+func (f *Filter) dispatchSummary(summary *Recorder) {
+	if len(f.Processors) > 0 && !f.Processors.Process(summary) {
+		return
+	}
+	f.dispatch(summary)
+}
+
+func (f *Filter) dispatch(r *Recorder) {
+	atomic.AddUint64(&f.records, 1)
+
+	var out *bytes.Buffer
+	for i, a := range f.Apps {
+		if a == nil || !a.Enabled(r) {
 			continue
 		}
 		if f.Layout == nil {
 			continue
 		}
-		if !encoded {
+		if out == nil {
+			f.enc.Lock()
+			defer f.enc.Unlock()
+			out = bufferPool.Get().(*bytes.Buffer)
+			out.Reset()
+			defer bufferPool.Put(out)
 			f.Layout.Encode(out, r)
-			encoded = true
 		}
-		a.Write(out.Bytes())
+		if _, err := a.Write(out.Bytes()); err != nil && f.ErrorHandler != nil {
+			f.ErrorHandler.HandleError(f.appName(i), "write", err)
+		}
+	}
+}
+
+// Needs returns the attributes used by the Enabler, the processors, the
+// suppressor, the layout and the appenders. See AttrsNeeder.
+func (f *Filter) Needs() Attrs {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n := NeedsOf(f.Enabler) | f.Processors.Needs()
+	if f.Suppressor != nil {
+		n |= f.Suppressor.Needs()
+	}
+	n |= NeedsOf(f.Layout)
+	for _, a := range f.Apps {
+		if a != nil {
+			n |= NeedsOf(a)
+		}
+	}
+	return n
+}
+
+// appName returns the name of the ith appender, which is the name of the
+// filter, or "<name>.<i>" for i > 0.
+func (f *Filter) appName(i int) string {
+	if i > 0 {
+		return fmt.Sprintf("%s.%d", f.Name, i)
+	}
+	return f.Name
+}
+
+// SetErrorHandler sets the error handler of the filter, and of all
+// appenders which implement ErrorHandlerSetter. A nil handler disables
+// error handling.
+func (f *Filter) SetErrorHandler(h ErrorHandler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.ErrorHandler = h
+	for i, a := range f.Apps {
+		if s, ok := a.(ErrorHandlerSetter); ok {
+			s.SetErrorHandler(f.appName(i), h)
+		}
+	}
+}
+
+// Flush writes the pending summary of the suppressor, then flushes all
+// appenders which implement Flusher.
+func (f *Filter) Flush() {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.Suppressor != nil {
+		if summary := f.Suppressor.Flush(); summary != nil {
+			f.dispatchSummary(summary)
+		}
+	}
+
+	for _, a := range f.Apps {
+		if fl, ok := a.(Flusher); ok {
+			fl.Flush()
+		}
 	}
 }
 
@@ -50,10 +222,27 @@ func (f *Filter) Dispatch(r *Recorder) {
 //
 // Notice: Close() removes all appenders from the filter.
 func (f *Filter) Close() {
-	for _, a := range f.Apps {
+	for _, a := range f.detach() {
 		if a != nil {
 			a.Close()
 		}
 	}
+}
+
+// detach writes the pending summary of the suppressor, then removes all
+// appenders from the filter, and returns them.
+func (f *Filter) detach() []Appender {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Suppressor != nil {
+		if summary := f.Suppressor.Flush(); summary != nil {
+			f.dispatchSummary(summary)
+		}
+	}
+
+	apps := f.Apps
 	f.Apps = nil
+	InvalidateLevels()
+	return apps
 }
@@ -13,9 +13,41 @@ type Layout interface {
 	Encode(out *bytes.Buffer, r *Recorder) int
 }
 
+// Attrs are the attributes of log recorders which are expensive to
+// collect, such as the caller.
+type Attrs uint
+
+const (
+	// AttrCaller is the caller of logging. See Recorder.Caller
+	AttrCaller Attrs = 1 << iota
+
+	// AttrAll includes all attributes.
+	AttrAll = AttrCaller
+)
+
+// AttrsNeeder is implemented by layouts, appenders, enablers and
+// processors which declare the attributes of log recorders they use, so
+// loggers skip collecting the attributes nobody uses.
+type AttrsNeeder interface {
+	Needs() Attrs
+}
+
+// NeedsOf returns the attributes used by v, which may be nil. Anything
+// which does not implement AttrsNeeder is assumed to use all attributes.
+func NeedsOf(v interface{}) Attrs {
+	if v == nil {
+		return 0
+	}
+	if n, ok := v.(AttrsNeeder); ok {
+		return n.Needs()
+	}
+	return AttrAll
+}
+
 type nopLayout struct{}
 
 // NewNopLayout returns a no-op Layout.
 func NewNopLayout() Layout                             { return &nopLayout{} }
 func (*nopLayout) Set(string, interface{}) error       { return nil }
 func (*nopLayout) Encode(*bytes.Buffer, *Recorder) int { return 0 }
+func (*nopLayout) Needs() Attrs                        { return 0 }
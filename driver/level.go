@@ -0,0 +1,175 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Leveler is implemented by enablers and appenders which have a level
+// that can be changed at runtime.
+type Leveler interface {
+	Level() int
+	SetLevel(n int)
+}
+
+// Unwrapper is implemented by enablers which wrap the next Enabler.
+type Unwrapper interface {
+	Unwrap() Enabler
+}
+
+// FindLeveler returns the Leveler of an enabler or an appender.
+// Wrapping enablers are unwrapped. Return nil if not found.
+func FindLeveler(v interface{}) Leveler {
+	for v != nil {
+		if lv, ok := v.(Leveler); ok {
+			return lv
+		}
+		u, ok := v.(Unwrapper)
+		if !ok {
+			return nil
+		}
+		next := u.Unwrap()
+		if next == nil {
+			return nil
+		}
+		v = next
+	}
+	return nil
+}
+
+// MinLeveler is implemented by enablers which accept no level below the
+// minimum level.
+type MinLeveler interface {
+	MinLevel() int
+}
+
+// The minimum levels of enablers which accept all levels or none.
+const (
+	MinLevelAll  = math.MinInt32
+	MinLevelNone = math.MaxInt32
+)
+
+// MinLevel returns the minimum level accepted by an enabler or an appender,
+// which is the lowest by LevelRank.
+// Wrapping enablers are unwrapped. Return MinLevelAll if it is unknown.
+func MinLevel(v interface{}) int {
+	for v != nil {
+		switch x := v.(type) {
+		case MinLeveler:
+			return x.MinLevel()
+		case Leveler:
+			return x.Level()
+		}
+		u, ok := v.(Unwrapper)
+		if !ok {
+			break
+		}
+		v = u.Unwrap()
+	}
+	return MinLevelAll
+}
+
+// LevelStep is the distance between the ranks of consecutive levels. See
+// LevelRank.
+const LevelStep = 10
+
+var (
+	rankMu sync.Mutex   // serializes RankLevel
+	ranks  atomic.Value // map[int]int64, copied on write
+	ranked int32        // the number of ranked levels
+)
+
+// LevelRank returns the rank of the level, by which levels are ordered.
+// It is the level multiplied by LevelStep, unless the level is ranked by
+// RankLevel. So the levels are ordered by number, while a ranked level may
+// be ordered between consecutive levels.
+func LevelRank(n int) int64 {
+	if atomic.LoadInt32(&ranked) > 0 {
+		if r, ok := ranks.Load().(map[int]int64)[n]; ok {
+			return r
+		}
+	}
+	return int64(n) * LevelStep
+}
+
+// LevelBelow returns true if the level a is ordered below the level b.
+// Enablers and appenders should compare levels with LevelBelow, instead
+// of comparing the numbers.
+func LevelBelow(a, b int) bool {
+	if atomic.LoadInt32(&ranked) == 0 {
+		return a < b
+	}
+	return LevelRank(a) < LevelRank(b)
+}
+
+// RankLevel sets the rank of the level n. For example, the rank 35 orders
+// a level between 3 and 4, whose ranks are 30 and 40. The rank n*LevelStep
+// restores the default order. See LevelRank.
+func RankLevel(n int, rank int) {
+	rankMu.Lock()
+	defer rankMu.Unlock()
+
+	old, _ := ranks.Load().(map[int]int64)
+	m := make(map[int]int64, len(old)+1)
+	for i, r := range old {
+		m[i] = r
+	}
+	if r := int64(rank); r == int64(n)*LevelStep {
+		delete(m, n)
+	} else {
+		m[n] = r
+	}
+	ranks.Store(m)
+	atomic.StoreInt32(&ranked, int32(len(m)))
+	InvalidateLevels()
+}
+
+// generation is increased whenever levels, filters or layouts are changed.
+var generation uint32 = 1
+
+// LevelGeneration returns the generation of levels. Loggers cache their
+// minimum enabled level and the attributes needed by layouts, which are
+// valid until the generation changes.
+func LevelGeneration() uint32 {
+	return atomic.LoadUint32(&generation)
+}
+
+// InvalidateLevels increases the generation of levels, so the cached
+// minimum levels of all loggers are computed again. It should be called
+// after changing anything which enables or disables levels, except the
+// levels of AtomicLevel and Filter.Update, which call it themselves.
+func InvalidateLevels() {
+	atomic.AddUint32(&generation, 1)
+}
+
+// AtomicLevel is an Enabler which accepts logging recorder's level at or
+// above the level. The level is safe to be read and changed concurrently,
+// so one AtomicLevel may be shared by the logger and filters.
+//
+// The zero value accepts all levels at or above 0.
+type AtomicLevel struct {
+	n int32
+}
+
+// NewAtomicLevel creates an atomic level Enabler.
+func NewAtomicLevel(n int) *AtomicLevel {
+	return &AtomicLevel{n: int32(n)}
+}
+
+// Level returns the level.
+func (a *AtomicLevel) Level() int { return int(atomic.LoadInt32(&a.n)) }
+
+// SetLevel changes the level.
+func (a *AtomicLevel) SetLevel(n int) {
+	atomic.StoreInt32(&a.n, int32(n))
+	InvalidateLevels()
+}
+
+// Enabled returns true if the recorder's level is at or above the level.
+func (a *AtomicLevel) Enabled(r *Recorder) bool { return !LevelBelow(r.Level, a.Level()) }
+
+// Needs returns no attributes.
+func (a *AtomicLevel) Needs() Attrs { return 0 }
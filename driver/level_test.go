@@ -0,0 +1,108 @@
+package driver
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAtomicLevel(t *testing.T) {
+	a := NewAtomicLevel(4)
+	if a.Enabled(&Recorder{Level: 3}) || !a.Enabled(&Recorder{Level: 4}) {
+		t.Errorf("Level 4 should accept at or above 4 only")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			a.SetLevel(n)
+			a.Enabled(&Recorder{Level: n})
+		}(i)
+	}
+	wg.Wait()
+
+	a.SetLevel(2)
+	if got, want := a.Level(), 2; got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+}
+
+func TestFindLeveler(t *testing.T) {
+	a := NewAtomicLevel(4)
+	if FindLeveler(a) != Leveler(a) {
+		t.Errorf("AtomicLevel should be a Leveler")
+	}
+	if FindLeveler(NewSampler(a, time.Second, 1, 1)) != Leveler(a) {
+		t.Errorf("Sampler should be unwrapped")
+	}
+	if FindLeveler(NewSampler(nil, time.Second, 1, 1)) != nil {
+		t.Errorf("Sampler without level should not be a Leveler")
+	}
+	if FindLeveler(AtAbove(4)) != nil {
+		t.Errorf("Enabler func should not be a Leveler")
+	}
+}
+
+func TestMinLevel(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want int
+	}{
+		{nil, MinLevelAll},
+		{NewAtomicLevel(4), 4},
+		{AtAbove(3), 3},
+		{MatchLevel(5), 5},
+		{DenyAll(), MinLevelNone},
+		{AcceptAll(), MinLevelAll},
+		{NewSampler(NewAtomicLevel(6), time.Second, 1, 1), 6},
+		{NewSampler(nil, time.Second, 1, 1), MinLevelAll},
+		{&Filter{Enabler: AtAbove(2)}, 2},
+		{&Filter{Enabler: AtAbove(2), Apps: []Appender{nil}}, 2},
+	}
+	for _, tt := range tests {
+		if got := MinLevel(tt.v); got != tt.want {
+			t.Errorf("%T: got %d, want %d", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestLevelGeneration(t *testing.T) {
+	g := LevelGeneration()
+	NewAtomicLevel(4).SetLevel(2)
+	if LevelGeneration() == g {
+		t.Errorf("Changing level should increase the generation")
+	}
+	g = LevelGeneration()
+	new(Filter).Update(func(f *Filter) {})
+	if LevelGeneration() == g {
+		t.Errorf("Updating filter should increase the generation")
+	}
+}
+
+func TestRankLevel(t *testing.T) {
+	const notice = 100
+
+	if !LevelBelow(3, 4) || LevelBelow(4, 4) || LevelBelow(notice, 4) {
+		t.Errorf("Unranked levels should be ordered by number")
+	}
+
+	g := LevelGeneration()
+	RankLevel(notice, 4*LevelStep+5)
+	defer RankLevel(notice, notice*LevelStep)
+	if LevelGeneration() == g {
+		t.Errorf("Ranking level should increase the generation")
+	}
+	if got, want := LevelRank(notice), int64(45); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	if !LevelBelow(4, notice) || !LevelBelow(notice, 5) {
+		t.Errorf("Ranked level should be ordered between 4 and 5")
+	}
+	if !NewAtomicLevel(notice).Enabled(&Recorder{Level: 5}) || AtAbove(notice).Enabled(&Recorder{Level: 4}) {
+		t.Errorf("Enablers should compare levels by rank")
+	}
+}
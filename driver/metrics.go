@@ -0,0 +1,88 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"sync/atomic"
+)
+
+// Stats is the output statistics of an appender.
+type Stats struct {
+	Bytes     uint64 `json:"bytes"`               // encoded bytes written
+	Errors    uint64 `json:"errors"`              // write errors
+	Rotations uint64 `json:"rotations,omitempty"` // files rotated
+}
+
+// Statser is implemented by appenders which count their output.
+type Statser interface {
+	Stats() Stats
+}
+
+// StatsCounter counts the bytes written and the write errors of an
+// appender. It is safe for concurrent use. The zero value is ready to use.
+type StatsCounter struct {
+	bytes  uint64
+	errors uint64
+}
+
+// Wrote counts the result of writing n bytes.
+func (c *StatsCounter) Wrote(n int, err error) {
+	if n > 0 {
+		atomic.AddUint64(&c.bytes, uint64(n))
+	}
+	if err != nil {
+		atomic.AddUint64(&c.errors, 1)
+	}
+}
+
+// Stats returns the current statistics.
+func (c *StatsCounter) Stats() Stats {
+	return Stats{
+		Bytes:  atomic.LoadUint64(&c.bytes),
+		Errors: atomic.LoadUint64(&c.errors),
+	}
+}
+
+// AppenderMetrics is the metrics of an appender of a filter.
+type AppenderMetrics struct {
+	Name string `json:"name"`           // the name of the appender. See Filter.SetErrorHandler
+	Type string `json:"type,omitempty"` // the registered name of the appender's type. See NameOf
+	Stats
+	Queue   int    `json:"queue"`   // the number of queued log recorders, or -1 if unknown. See Pending
+	Dropped uint64 `json:"dropped"` // the number of log recorders dropped. See Dropper
+}
+
+// FilterMetrics is the metrics of a filter and its appenders.
+type FilterMetrics struct {
+	Name      string            `json:"name"`
+	Records   uint64            `json:"records"` // the number of log recorders dispatched to the appenders
+	Appenders []AppenderMetrics `json:"appenders,omitempty"`
+}
+
+// Metrics returns the current metrics of the filter and its appenders.
+func (f *Filter) Metrics() FilterMetrics {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	m := FilterMetrics{
+		Name:    f.Name,
+		Records: atomic.LoadUint64(&f.records),
+	}
+	for i, a := range f.Apps {
+		if a == nil {
+			continue
+		}
+		am := AppenderMetrics{Name: f.appName(i), Type: NameOf(a), Queue: -1}
+		if s, ok := a.(Statser); ok {
+			am.Stats = s.Stats()
+		}
+		if p, ok := a.(Pending); ok {
+			am.Queue = p.Pending()
+		}
+		if d, ok := a.(Dropper); ok {
+			am.Dropped = d.Dropped()
+		}
+		m.Appenders = append(m.Appenders, am)
+	}
+	return m
+}
@@ -0,0 +1,23 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+// Optioner is implemented by appenders, layouts and enablers which report
+// their current options.
+type Optioner interface {
+	// Options returns the name-value pairs of options, which can be set
+	// back with Set, in the form of SetOptions arguments.
+	Options() []interface{}
+}
+
+// SetOption sets the value of the name in name-value pairs, or appends
+// the pair if the name is not found.
+func SetOption(pairs []interface{}, name string, v interface{}) []interface{} {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if k, ok := pairs[i].(string); ok && k == name {
+			pairs[i+1] = v
+			return pairs
+		}
+	}
+	return append(pairs, name, v)
+}
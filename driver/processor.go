@@ -0,0 +1,326 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/ccpaging/nxlog4go/cast"
+)
+
+// Processor is an interface for anything that enriches, rewrites or drops
+// log recorders before they are dispatched.
+type Processor interface {
+	// Process may change the recorder in place.
+	// Return false to drop the recorder.
+	Process(*Recorder) bool
+}
+
+// ProcessorFunc is a function which implements Processor.
+type ProcessorFunc func(*Recorder) bool
+
+// Process calls fn(r).
+func (fn ProcessorFunc) Process(r *Recorder) bool { return fn(r) }
+
+// Processors is a chain of processors, which are called in order.
+type Processors []Processor
+
+// Process calls all processors in order until one of them drops the recorder.
+// Return false if the recorder is dropped.
+func (ps Processors) Process(r *Recorder) bool {
+	for _, p := range ps {
+		if p != nil && !p.Process(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Needs returns the attributes used by all processors.
+func (ps Processors) Needs() (n Attrs) {
+	for _, p := range ps {
+		if p != nil {
+			n |= NeedsOf(p)
+		}
+	}
+	return
+}
+
+/** Register **/
+
+// ProcessorOpener creates a processor with name-value pair options.
+type ProcessorOpener func(args ...interface{}) (Processor, error)
+
+var processors = map[string]ProcessorOpener{
+	"fields": func(args ...interface{}) (Processor, error) {
+		return openProcessor(NewFieldsProcessor(), args...)
+	},
+	"env": func(args ...interface{}) (Processor, error) {
+		return openProcessor(NewEnvProcessor(), args...)
+	},
+}
+
+// RegisterProcessor is called by 3rd processor's init() function
+// to register the new processor opener.
+func RegisterProcessor(name string, open ProcessorOpener) {
+	if name == "" {
+		return
+	}
+	if open == nil {
+		delete(processors, name)
+		return
+	}
+	processors[name] = open
+}
+
+// OpenProcessor creates the named processor with name-value pair options.
+//
+// Return new processor and error
+func OpenProcessor(name string, args ...interface{}) (Processor, error) {
+	if open, ok := processors[name]; ok {
+		return open(args...)
+	}
+	return nil, errors.New("Not register processor " + name)
+}
+
+// ProcessorNameOf returns the registered name of the processor's type,
+// which is found by opening the registered processors without options.
+// Return "" if the type is not registered.
+func ProcessorNameOf(p Processor) string {
+	if p == nil {
+		return ""
+	}
+	t := reflect.TypeOf(p)
+	for name, open := range processors {
+		if q, err := open(); err == nil && reflect.TypeOf(q) == t {
+			return name
+		}
+	}
+	return ""
+}
+
+type setter interface {
+	Set(k string, v interface{}) error
+}
+
+func openProcessor(p Processor, args ...interface{}) (Processor, error) {
+	ops, idx, err := ArgsToMap(args...)
+	if err != nil {
+		return nil, err
+	}
+	s := p.(setter)
+	for _, k := range idx {
+		if err := s.Set(k, ops[k]); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+/** Built-in processors **/
+
+// FieldsProcessor adds static typed fields to every recorder, such as the
+// build version of the application.
+type FieldsProcessor struct {
+	mu     sync.Mutex
+	fields []Field
+}
+
+// NewFieldsProcessor creates a processor which adds the fields.
+func NewFieldsProcessor(fields ...Field) *FieldsProcessor {
+	return &FieldsProcessor{fields: fields}
+}
+
+// Process appends the fields to the recorder.
+func (p *FieldsProcessor) Process(r *Recorder) bool {
+	p.mu.Lock()
+	fields := p.fields
+	p.mu.Unlock()
+	r.Typed = append(r.Typed[:len(r.Typed):len(r.Typed)], fields...)
+	return true
+}
+
+// Needs returns no attributes.
+func (p *FieldsProcessor) Needs() Attrs { return 0 }
+
+// SetOptions sets name-value pair options.
+//
+// Return *FieldsProcessor.
+func (p *FieldsProcessor) SetOptions(args ...interface{}) *FieldsProcessor {
+	ops, idx, _ := ArgsToMap(args...)
+	for _, k := range idx {
+		p.Set(k, ops[k])
+	}
+	return p
+}
+
+// Fields returns a copy of the fields.
+func (p *FieldsProcessor) Fields() []Field {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Field(nil), p.fields...)
+}
+
+// Add adds or replaces the field with the same key.
+func (p *FieldsProcessor) Add(f Field) *FieldsProcessor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fields := make([]Field, 0, len(p.fields)+1)
+	for _, old := range p.fields {
+		if old.Key != f.Key {
+			fields = append(fields, old)
+		}
+	}
+	p.fields = append(fields, f)
+	return p
+}
+
+// Options returns the fields as name-value pairs. See Set.
+func (p *FieldsProcessor) Options() (pairs []interface{}) {
+	for _, f := range p.Fields() {
+		pairs = append(pairs, f.Key, f.Value())
+	}
+	return
+}
+
+// Set adds or replaces a string field, which key is the name of option.
+//
+// Return error.
+func (p *FieldsProcessor) Set(k string, v interface{}) error {
+	s, err := cast.ToString(v)
+	if err != nil {
+		return err
+	}
+	p.Add(String(k, s))
+	return nil
+}
+
+// EnvProcessor adds fields which are read from the environment of the
+// process once, such as the host name, the process id, and the Kubernetes
+// pod name and namespace set by the downward API.
+//
+// By default, it adds "host" and "pid".
+type EnvProcessor struct {
+	fields FieldsProcessor
+
+	mu       sync.Mutex
+	hostname string   // the key of the host name field, or "" for none
+	pid      string   // the key of the process id field, or "" for none
+	vars     []string // pairs of field key and variable name
+}
+
+// NewEnvProcessor creates a processor which adds the host name and the
+// process id. Environment variables can be added with Set, such as
+//
+//	NewEnvProcessor().SetOptions("pod", "POD_NAME", "namespace", "POD_NAMESPACE")
+func NewEnvProcessor() *EnvProcessor {
+	p := new(EnvProcessor)
+	p.Set("hostname", "host")
+	p.Set("pid", "pid")
+	return p
+}
+
+// SetOptions sets name-value pair options.
+//
+// Return *EnvProcessor.
+func (p *EnvProcessor) SetOptions(args ...interface{}) *EnvProcessor {
+	ops, idx, _ := ArgsToMap(args...)
+	for _, k := range idx {
+		p.Set(k, ops[k])
+	}
+	return p
+}
+
+// Options returns the current name-value pair options. See Set.
+func (p *EnvProcessor) Options() []interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pairs := []interface{}{"hostname", p.hostname, "pid", p.pid}
+	for i := 0; i+1 < len(p.vars); i += 2 {
+		pairs = append(pairs, p.vars[i], p.vars[i+1])
+	}
+	return pairs
+}
+
+// Set sets name-value option with:
+//
+//	hostname - The key of the host name field. "" disables the field
+//	pid      - The key of the process id field. "" disables the field
+//
+// Other names are the keys of fields, whose values are read from the
+// environment variables named by the option values. Fields of unset
+// variables are not added.
+//
+// Return error.
+func (p *EnvProcessor) Set(k string, v interface{}) (err error) {
+	var s string
+	if s, err = cast.ToString(v); err != nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch k {
+	case "hostname":
+		p.hostname = s
+	case "pid":
+		p.pid = s
+	default:
+		if k == "" {
+			return fmt.Errorf("empty field key of environment variable %s", s)
+		}
+		p.vars = setVar(p.vars, k, s)
+	}
+	p.reset()
+	return
+}
+
+func setVar(pairs []string, k, v string) []string {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i] == k {
+			pairs[i+1] = v
+			return pairs
+		}
+	}
+	return append(pairs, k, v)
+}
+
+// Process appends the fields to the recorder.
+func (p *EnvProcessor) Process(r *Recorder) bool {
+	return p.fields.Process(r)
+}
+
+// Needs returns no attributes.
+func (p *EnvProcessor) Needs() Attrs { return 0 }
+
+// Fields returns a copy of the fields.
+func (p *EnvProcessor) Fields() []Field {
+	return p.fields.Fields()
+}
+
+// reset reads the environment, and rebuilds the fields.
+func (p *EnvProcessor) reset() {
+	var fields []Field
+	if p.hostname != "" {
+		if host, err := os.Hostname(); err == nil {
+			fields = append(fields, String(p.hostname, host))
+		}
+	}
+	if p.pid != "" {
+		fields = append(fields, Int64(p.pid, int64(os.Getpid())))
+	}
+	for i := 0; i+1 < len(p.vars); i += 2 {
+		if v, ok := os.LookupEnv(p.vars[i+1]); ok {
+			fields = append(fields, String(p.vars[i], v))
+		}
+	}
+
+	p.fields.mu.Lock()
+	p.fields.fields = fields
+	p.fields.mu.Unlock()
+}
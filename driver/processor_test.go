@@ -0,0 +1,77 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFieldsProcessor(t *testing.T) {
+	p := NewFieldsProcessor(String("version", "1.0"))
+	p.Set("env", "prod")
+	p.Set("version", "1.1")
+
+	typed := make([]Field, 1, 4)
+	typed[0] = String("k", "v")
+	r := &Recorder{Typed: typed[:1]}
+	if !p.Process(r) {
+		t.Errorf("Fields processor should not drop")
+	}
+	fields, index := r.Fields()
+	if got, want := len(index), 3; got != want {
+		t.Fatalf("   got %d %v", got, index)
+	}
+	if got, want := fields["version"], "1.1"; got != want {
+		t.Errorf("   got %v", got)
+		t.Errorf("  want %v", want)
+	}
+	if typed[:2][1].Key != "" {
+		t.Errorf("The original fields should not be changed")
+	}
+}
+
+func TestEnvProcessor(t *testing.T) {
+	os.Setenv("TEST_POD_NAMESPACE", "default")
+	defer os.Unsetenv("TEST_POD_NAMESPACE")
+
+	p, err := OpenProcessor("env", "namespace", "TEST_POD_NAMESPACE", "pod", "TEST_UNSET_POD_NAME")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Recorder{}
+	p.Process(r)
+	fields, _ := r.Fields()
+	if got, want := fields["pid"], int64(os.Getpid()); got != want {
+		t.Errorf("   got %v", got)
+		t.Errorf("  want %v", want)
+	}
+	if _, ok := fields["host"]; !ok {
+		t.Errorf("The host name field should be added")
+	}
+	if got, want := fields["namespace"], "default"; got != want {
+		t.Errorf("   got %v", got)
+		t.Errorf("  want %v", want)
+	}
+	if _, ok := fields["pod"]; ok {
+		t.Errorf("Unset variable should not be added")
+	}
+
+	if _, err := OpenProcessor("none"); err == nil {
+		t.Errorf("Unknown processor should fail")
+	}
+}
+
+func TestProcessorsDrop(t *testing.T) {
+	n := 0
+	count := ProcessorFunc(func(r *Recorder) bool { n++; return true })
+	odd := ProcessorFunc(func(r *Recorder) bool { return r.Line%2 == 1 })
+	ps := Processors{odd, count}
+	for i := 0; i < 10; i++ {
+		ps.Process(&Recorder{Line: i})
+	}
+	if got, want := n, 5; got != want {
+		t.Errorf("   got %v", got)
+		t.Errorf("  want %v", want)
+	}
+}
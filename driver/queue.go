@@ -0,0 +1,205 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ccpaging/nxlog4go/cast"
+)
+
+// Overflow policies of Queue, when the queue is full.
+const (
+	OverflowBlock      int = iota // block until there is room
+	OverflowDropNewest            // drop the recorder being queued
+	OverflowDropOldest            // drop the oldest queued recorder
+	OverflowTimeout               // block until there is room or timeout, then drop the recorder
+)
+
+var overflowNames = []string{"block", "dropNewest", "dropOldest", "timeout"}
+
+// Defaults of Queue.
+var (
+	DefaultQueueCapacity   = 32
+	DefaultOverflowTimeout = time.Second
+)
+
+// Dropper is implemented by asynchronous appenders, which report the
+// number of log recorders dropped when the queue overflows.
+type Dropper interface {
+	Dropped() uint64
+}
+
+// Queue is a bounded queue of log recorders for asynchronous appenders.
+// Recorders are received from C by the goroutine of the appender.
+//
+// Put may be called concurrently. The capacity can not be changed after
+// C is called by the appender.
+type Queue struct {
+	ch      atomic.Value // chan *Recorder
+	started int32        // C is called
+	policy  int32
+	timeout int64 // in nanoseconds
+
+	dropped uint64
+}
+
+// NewQueue creates a queue with the capacity and the overflow policy.
+// A capacity <= 0 means DefaultQueueCapacity.
+func NewQueue(capacity int, policy int) *Queue {
+	if capacity <= 0 {
+		capacity = DefaultQueueCapacity
+	}
+	q := &Queue{
+		policy:  int32(policy),
+		timeout: int64(DefaultOverflowTimeout),
+	}
+	q.ch.Store(make(chan *Recorder, capacity))
+	return q
+}
+
+// C returns the channel of queued recorders. Receivers should Release
+// the recorders after writing.
+func (q *Queue) C() <-chan *Recorder {
+	atomic.StoreInt32(&q.started, 1)
+	return q.load()
+}
+
+func (q *Queue) load() chan *Recorder {
+	return q.ch.Load().(chan *Recorder)
+}
+
+// Put queues the recorder, which is owned by the queue then. It is a Clone
+// usually. See Recorder. If the queue is full, the overflow policy decides
+// whether to block or drop. Dropped recorders are released and counted.
+// Return false if the recorder is dropped.
+func (q *Queue) Put(r *Recorder) bool {
+	ch := q.load()
+
+	select {
+	case ch <- r:
+		return true
+	default:
+	}
+
+	switch int(atomic.LoadInt32(&q.policy)) {
+	case OverflowDropNewest:
+		q.drop(r)
+		return false
+	case OverflowDropOldest:
+		for {
+			select {
+			case old := <-ch:
+				q.drop(old)
+			default:
+			}
+			select {
+			case ch <- r:
+				return true
+			default:
+			}
+		}
+	case OverflowTimeout:
+		t := time.NewTimer(time.Duration(atomic.LoadInt64(&q.timeout)))
+		defer t.Stop()
+		select {
+		case ch <- r:
+			return true
+		case <-t.C:
+			q.drop(r)
+			return false
+		}
+	default:
+		ch <- r
+		return true
+	}
+}
+
+func (q *Queue) drop(r *Recorder) {
+	atomic.AddUint64(&q.dropped, 1)
+	r.Release()
+}
+
+// Len returns the number of queued recorders. See Pending.
+func (q *Queue) Len() int {
+	return len(q.load())
+}
+
+// Cap returns the capacity of the queue.
+func (q *Queue) Cap() int {
+	return cap(q.load())
+}
+
+// Dropped returns the number of dropped recorders.
+func (q *Queue) Dropped() uint64 {
+	return atomic.LoadUint64(&q.dropped)
+}
+
+// Close closes the channel. Put must not be called after closing.
+func (q *Queue) Close() {
+	close(q.load())
+}
+
+// Options returns the current name-value pair options. See Set.
+func (q *Queue) Options() []interface{} {
+	opts := []interface{}{
+		"queue", q.Cap(),
+		"overflow", overflowNames[atomic.LoadInt32(&q.policy)],
+	}
+	if int(atomic.LoadInt32(&q.policy)) == OverflowTimeout {
+		opts = append(opts, "overflowTimeout", time.Duration(atomic.LoadInt64(&q.timeout)).String())
+	}
+	return opts
+}
+
+// Set sets name-value option with:
+//
+//	queue           - The capacity of the queue. Set it before logging
+//	overflow        - "block", "dropNewest", "dropOldest", or "timeout".
+//	                  The default is "block"
+//	overflowTimeout - The timeout of the "timeout" policy, such as "100ms"
+//
+// Return error.
+func (q *Queue) Set(k string, v interface{}) (err error) {
+	var (
+		n   int
+		str string
+	)
+
+	switch k {
+	case "queue":
+		if n, err = cast.ToInt(v); err == nil {
+			if n <= 0 {
+				return fmt.Errorf("queue capacity %d should be positive", n)
+			}
+			if atomic.LoadInt32(&q.started) != 0 {
+				return fmt.Errorf("queue capacity can not be changed while logging")
+			}
+			q.ch.Store(make(chan *Recorder, n))
+		}
+	case "overflow":
+		if str, err = cast.ToString(v); err == nil {
+			for i, name := range overflowNames {
+				if name == str {
+					atomic.StoreInt32(&q.policy, int32(i))
+					return
+				}
+			}
+			err = fmt.Errorf("unknown overflow policy %q", str)
+		}
+	case "overflowTimeout":
+		var d time.Duration
+		if str, err = cast.ToString(v); err == nil {
+			if d, err = time.ParseDuration(str); err == nil && d > 0 {
+				atomic.StoreInt64(&q.timeout, int64(d))
+			}
+		} else if n, err = cast.ToInt(v); err == nil && n > 0 {
+			atomic.StoreInt64(&q.timeout, int64(n)*int64(time.Second))
+		}
+	default:
+		return fmt.Errorf("unknown option name %s, value %#v of type %T", k, v, v)
+	}
+	return
+}
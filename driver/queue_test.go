@@ -0,0 +1,109 @@
+package driver
+
+import (
+	"testing"
+	"time"
+)
+
+func fillQueue(q *Queue, n int) {
+	for i := 0; i < n; i++ {
+		r := NewRecorder()
+		r.Message = string(rune('a' + i))
+		q.Put(r)
+	}
+}
+
+func TestQueueOverflow(t *testing.T) {
+	for _, tt := range []struct {
+		policy  int
+		put     bool
+		first   string
+		dropped uint64
+	}{
+		{OverflowDropNewest, false, "a", 1},
+		{OverflowDropOldest, true, "b", 1},
+		{OverflowTimeout, false, "a", 1},
+	} {
+		q := NewQueue(2, tt.policy)
+		q.Set("overflowTimeout", "10ms")
+		fillQueue(q, 2)
+
+		r := NewRecorder()
+		r.Message = "c"
+		if got, want := q.Put(r), tt.put; got != want {
+			t.Errorf("%s: put got %v, want %v", overflowNames[tt.policy], got, want)
+		}
+		if got, want := q.Len(), 2; got != want {
+			t.Errorf("%s: len got %d, want %d", overflowNames[tt.policy], got, want)
+		}
+		if got, want := q.Dropped(), tt.dropped; got != want {
+			t.Errorf("%s: dropped got %d, want %d", overflowNames[tt.policy], got, want)
+		}
+		if r := <-q.C(); r.Message != tt.first {
+			t.Errorf("%s: first got %q, want %q", overflowNames[tt.policy], r.Message, tt.first)
+		}
+	}
+}
+
+func TestQueueBlock(t *testing.T) {
+	q := NewQueue(1, OverflowBlock)
+	fillQueue(q, 1)
+
+	done := make(chan bool)
+	go func() {
+		done <- q.Put(NewRecorder())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Put should block while the queue is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+	<-q.C()
+	if !<-done {
+		t.Errorf("Put should not drop")
+	}
+	if q.Dropped() != 0 {
+		t.Errorf("Dropped should be 0")
+	}
+}
+
+func TestQueueSet(t *testing.T) {
+	q := NewQueue(0, OverflowBlock)
+	if got, want := q.Cap(), DefaultQueueCapacity; got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+
+	for _, kv := range [][2]string{
+		{"queue", "8"},
+		{"overflow", "timeout"},
+		{"overflowTimeout", "100ms"},
+	} {
+		if err := q.Set(kv[0], kv[1]); err != nil {
+			t.Errorf("Set(%q, %q): %v", kv[0], kv[1], err)
+		}
+	}
+	opts := q.Options()
+	want := []interface{}{"queue", 8, "overflow", "timeout", "overflowTimeout", "100ms"}
+	if len(opts) != len(want) {
+		t.Fatalf("Options got %v, want %v", opts, want)
+	}
+	for i := range want {
+		if opts[i] != want[i] {
+			t.Errorf("Options got %v, want %v", opts, want)
+			break
+		}
+	}
+
+	if err := q.Set("overflow", "unknown"); err == nil {
+		t.Errorf("Unknown overflow policy should fail")
+	}
+	if err := q.Set("queue", 0); err == nil {
+		t.Errorf("Zero capacity should fail")
+	}
+	q.C()
+	if err := q.Set("queue", 16); err == nil {
+		t.Errorf("Capacity should not be changed after started")
+	}
+}
@@ -1,6 +1,8 @@
 package driver
 
 import (
+	"runtime"
+	"sync"
 	"time"
 )
 
@@ -8,14 +10,74 @@ import (
 // It is the final or intermediate logging entry also. It contains all
 // the fields passed with With(key, value, ...). It's finally logged
 // when Trace, Debug, Info, Warn, Error, Fatal or Panic is called on it.
+//
+// Recorders are pooled. A recorder passed to Dispatch, Enabled, Encode or
+// Process is borrowed, and is valid only during the call. The logger
+// releases the recorders which it creates after dispatching. To keep a
+// recorder after the call, such as queueing it to another goroutine, keep
+// a Clone, and Release the clone when done.
 type Recorder struct {
 	Prefix  string    // The message prefix
-	Source  string    // The message source
+	Source  string    // The message source. See Caller
 	Line    int       // The source line
+	PC      uintptr   // The program counter of the caller, as runtime.Callers returns
 	Level   int       // The log level
 	Message string    // The log message
 	Created time.Time // The time at which the log message was created (nanoseconds)
 	Values  []interface{}
+	Typed   []Field `json:"-"` // The typed fields. See Field
+}
+
+var recorderPool = sync.Pool{
+	New: func() interface{} {
+		return new(Recorder)
+	},
+}
+
+// NewRecorder returns an empty recorder from the pool.
+// Call Release when the recorder is not used any more.
+func NewRecorder() *Recorder {
+	return recorderPool.Get().(*Recorder)
+}
+
+// Release resets the recorder, and puts it back to the pool. The recorder
+// must not be used after releasing.
+func (r *Recorder) Release() {
+	*r = Recorder{}
+	recorderPool.Put(r)
+}
+
+// Clone returns a shallow copy of the recorder from the pool. The
+// capacities of Values and Typed are limited, so appending to them does
+// not change the original. Call Release when the clone is not used any
+// more.
+func (r *Recorder) Clone() *Recorder {
+	c := NewRecorder()
+	*c = *r
+	c.Values = c.Values[:len(c.Values):len(c.Values)]
+	c.Typed = c.Typed[:len(c.Typed):len(c.Typed)]
+	return c
+}
+
+// Caller returns the source and the line of the caller. Loggers record
+// only the program counter, which is resolved when Caller is called first.
+// Encoders should call Caller instead of reading Source and Line.
+func (r *Recorder) Caller() (source string, line int) {
+	if r.Source == "" && r.PC != 0 {
+		f := r.Frame()
+		r.Source, r.Line = f.File, f.Line
+	}
+	return r.Source, r.Line
+}
+
+// Frame returns the frame of the caller, including the function name.
+// Without the program counter, only the file and the line are set.
+func (r *Recorder) Frame() runtime.Frame {
+	if r.PC == 0 {
+		return runtime.Frame{File: r.Source, Line: r.Line}
+	}
+	f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	return f
 }
 
 // With sets sets values to the log record.
@@ -31,7 +93,21 @@ func (r *Recorder) WithMore(args ...interface{}) *Recorder {
 	return r
 }
 
+// WithFields appends typed fields to the log record.
+func (r *Recorder) WithFields(fields ...Field) *Recorder {
+	r.Typed = append(r.Typed, fields...)
+	return r
+}
+
 // Fields return the fields and index of the log record.
+// The typed fields are boxed and appended after the values.
 func (r *Recorder) Fields() (map[string]interface{}, []string) {
-	return LazyArgsToMap(r.Values...)
+	m, index := LazyArgsToMap(r.Values...)
+	for _, f := range r.Typed {
+		if _, ok := m[f.Key]; !ok {
+			index = append(index, f.Key)
+		}
+		m[f.Key] = f.Value()
+	}
+	return m, index
 }
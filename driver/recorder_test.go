@@ -2,6 +2,8 @@ package driver
 
 import (
 	"fmt"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -163,3 +165,40 @@ func TestRecorderWithValuesFunc2(t *testing.T) {
 		t.Errorf("  want %q", want)
 	}
 }
+
+func TestRecorderCaller(t *testing.T) {
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	_, file, line, _ := runtime.Caller(0)
+
+	r := &Recorder{PC: pcs[0]}
+	if source, n := r.Caller(); source != file || n != line-1 {
+		t.Errorf("   got %s:%d", source, n)
+		t.Errorf("  want %s:%d", file, line-1)
+	}
+	if f := r.Frame(); !strings.HasSuffix(f.Function, "TestRecorderCaller") {
+		t.Errorf("Function got %q", f.Function)
+	}
+
+	r = &Recorder{Source: "source", Line: 3}
+	if source, n := r.Caller(); source != "source" || n != 3 {
+		t.Errorf("Recorder without PC should keep the caller, got %s:%d", source, n)
+	}
+}
+
+func TestFilterNeeds(t *testing.T) {
+	f := &Filter{Enabler: NewAtomicLevel(0), Layout: NewNopLayout()}
+	if n := f.Needs(); n != 0 {
+		t.Errorf("Filter needs %v, want none", n)
+	}
+	f.Processors = Processors{ProcessorFunc(func(*Recorder) bool { return true })}
+	if n := f.Needs(); n != AttrAll {
+		t.Errorf("Unknown processors should need all, got %v", n)
+	}
+	f.Processors = nil
+	f.Enabler = NewSampler(nil, 0, 1, 1)
+	f.Enabler.(*Sampler).Set("sampleBy", "caller")
+	if n := f.Needs(); n != AttrCaller {
+		t.Errorf("Sampling by caller should need the caller, got %v", n)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ccpaging/nxlog4go/cast"
+)
+
+// Sampling keys.
+const (
+	SampleByMessage int = iota // sample by level and message
+	SampleByCaller             // sample by level and caller source line
+)
+
+const sampleCounters = 4096
+
+type sampleCounter struct {
+	resetAt int64
+	n       uint64
+}
+
+// Sampler is an Enabler which samples log recorders for high-volume log
+// sites. Within every tick, it accepts the first N recorders with the same
+// key, then every Mth recorder after that. Recorders are keyed by level and
+// message, or by level and caller.
+//
+// Counters are kept in a fixed-size hash table, so distinct keys may share
+// a counter in rare cases.
+type Sampler struct {
+	Enabler // The next Enabler, e.g. the level Enabler. May be nil
+
+	tick       int64 // in nanoseconds
+	first      uint64
+	thereafter uint64
+	by         int32 // SampleByMessage or SampleByCaller
+
+	counts  [sampleCounters]sampleCounter
+	dropped uint64
+}
+
+// NewSampler creates a sampler which accepts the first n recorders with the
+// same key in every tick, then every mth recorder after that. If m is zero,
+// all recorders after the first n are dropped.
+//
+// Recorders are passed to the next Enabler first, if it is not nil.
+func NewSampler(next Enabler, tick time.Duration, n, m int) *Sampler {
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return &Sampler{
+		Enabler:    next,
+		tick:       int64(tick),
+		first:      uint64(n),
+		thereafter: uint64(m),
+	}
+}
+
+// Enabled returns false if the recorder is dropped by the next Enabler or
+// by sampling.
+func (s *Sampler) Enabled(r *Recorder) bool {
+	if s.Enabler != nil && !s.Enabler.Enabled(r) {
+		return false
+	}
+
+	c := &s.counts[s.hash(r)%sampleCounters]
+	if s.count(c, r.Created.UnixNano()) {
+		return true
+	}
+	atomic.AddUint64(&s.dropped, 1)
+	return false
+}
+
+const (
+	offset32 = 2166136261
+	prime32  = 16777619
+)
+
+// hash returns the FNV-1a hash of the sampling key.
+func (s *Sampler) hash(r *Recorder) uint32 {
+	h := uint32(offset32)
+	h = (h ^ uint32(r.Level)) * prime32
+	key := r.Message
+	if atomic.LoadInt32(&s.by) == int32(SampleByCaller) {
+		var line int
+		key, line = r.Caller()
+		h = (h ^ uint32(line)) * prime32
+	}
+	for i := 0; i < len(key); i++ {
+		h = (h ^ uint32(key[i])) * prime32
+	}
+	return h
+}
+
+func (s *Sampler) count(c *sampleCounter, t int64) bool {
+	tick := atomic.LoadInt64(&s.tick)
+	resetAt := atomic.LoadInt64(&c.resetAt)
+	if resetAt <= t {
+		// A new tick. Only one goroutine resets the counter.
+		if atomic.CompareAndSwapInt64(&c.resetAt, resetAt, t+tick) {
+			atomic.StoreUint64(&c.n, 1)
+			return atomic.LoadUint64(&s.first) > 0
+		}
+	}
+
+	n := atomic.AddUint64(&c.n, 1)
+	first := atomic.LoadUint64(&s.first)
+	if n <= first {
+		return true
+	}
+	thereafter := atomic.LoadUint64(&s.thereafter)
+	return thereafter > 0 && (n-first)%thereafter == 0
+}
+
+// Needs returns the attributes used by sampling and by the next Enabler.
+func (s *Sampler) Needs() Attrs {
+	n := NeedsOf(s.Enabler)
+	if atomic.LoadInt32(&s.by) == int32(SampleByCaller) {
+		n |= AttrCaller
+	}
+	return n
+}
+
+// Unwrap returns the next Enabler.
+func (s *Sampler) Unwrap() Enabler {
+	return s.Enabler
+}
+
+// Dropped returns the number of recorders dropped by sampling.
+func (s *Sampler) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Options returns the current name-value pair options. See Set.
+func (s *Sampler) Options() []interface{} {
+	by := "message"
+	if atomic.LoadInt32(&s.by) == int32(SampleByCaller) {
+		by = "caller"
+	}
+	return []interface{}{
+		"sampleTick", time.Duration(atomic.LoadInt64(&s.tick)).String(),
+		"sampleFirst", atomic.LoadUint64(&s.first),
+		"sampleThereafter", atomic.LoadUint64(&s.thereafter),
+		"sampleBy", by,
+	}
+}
+
+// Set sets name-value option with:
+//
+//	sampleTick       - The sampling interval, such as "1s". It must be positive
+//	sampleFirst      - Accept the first n recorders with the same key in every tick
+//	sampleThereafter - Then accept every mth recorder. 0 drops the rest
+//	sampleBy         - "message", "caller". The default is "message"
+//
+// Return error.
+func (s *Sampler) Set(k string, v interface{}) (err error) {
+	var (
+		n   int
+		str string
+	)
+
+	switch k {
+	case "sampleTick":
+		var d time.Duration
+		if str, err = cast.ToString(v); err == nil {
+			d, err = time.ParseDuration(str)
+		} else if n, err = cast.ToInt(v); err == nil {
+			d = time.Duration(n) * time.Second
+		}
+		if err == nil && d <= 0 {
+			err = fmt.Errorf("sample tick %v should be positive", v)
+		}
+		if err == nil {
+			atomic.StoreInt64(&s.tick, int64(d))
+		}
+	case "sampleFirst":
+		if n, err = cast.ToInt(v); err == nil && n < 0 {
+			err = fmt.Errorf("sample first %v should not be negative", v)
+		}
+		if err == nil {
+			atomic.StoreUint64(&s.first, uint64(n))
+		}
+	case "sampleThereafter":
+		if n, err = cast.ToInt(v); err == nil && n < 0 {
+			err = fmt.Errorf("sample thereafter %v should not be negative", v)
+		}
+		if err == nil {
+			atomic.StoreUint64(&s.thereafter, uint64(n))
+		}
+	case "sampleBy":
+		if str, err = cast.ToString(v); err == nil {
+			switch str {
+			case "caller":
+				atomic.StoreInt32(&s.by, int32(SampleByCaller))
+			case "message":
+				atomic.StoreInt32(&s.by, int32(SampleByMessage))
+			default:
+				err = fmt.Errorf("unknown sample key %q", str)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown option name %s, value %#v of type %T", k, v, v)
+	}
+	return
+}
@@ -0,0 +1,85 @@
+package driver
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	s := NewSampler(AtAbove(1), time.Minute, 2, 3)
+	now := time.Now()
+
+	accepted := 0
+	for i := 0; i < 11; i++ {
+		if s.Enabled(&Recorder{Level: 1, Message: "same", Created: now}) {
+			accepted++
+		}
+	}
+	// first 2, then the 5th, 8th and 11th
+	if got, want := accepted, 5; got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	if got, want := s.Dropped(), uint64(6); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+
+	if !s.Enabled(&Recorder{Level: 1, Message: "other", Created: now}) {
+		t.Errorf("Other message should not be sampled")
+	}
+	if !s.Enabled(&Recorder{Level: 1, Message: "same", Created: now.Add(time.Minute)}) {
+		t.Errorf("Next tick should reset the counter")
+	}
+	if s.Enabled(&Recorder{Level: 0, Message: "low", Created: now}) {
+		t.Errorf("Next Enabler should drop the level")
+	}
+	if got, want := s.Dropped(), uint64(6); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+}
+
+func TestSamplerSet(t *testing.T) {
+	s := NewSampler(nil, 0, 1, 0)
+	for _, kv := range [][2]string{
+		{"sampleTick", "1m"},
+		{"sampleFirst", "1"},
+		{"sampleThereafter", "0"},
+		{"sampleBy", "caller"},
+	} {
+		if err := s.Set(kv[0], kv[1]); err != nil {
+			t.Errorf("Set(%q, %q): %v", kv[0], kv[1], err)
+		}
+	}
+	if err := s.Set("sampleBy", "level"); err == nil {
+		t.Errorf("Set(sampleBy, level) should fail")
+	}
+	for _, v := range []interface{}{"0s", "-1s", 0, -1} {
+		if err := s.Set("sampleTick", v); err == nil {
+			t.Errorf("Set(sampleTick, %#v) should fail", v)
+		}
+	}
+	for _, k := range []string{"sampleFirst", "sampleThereafter"} {
+		for _, v := range []interface{}{"-1", -1} {
+			if err := s.Set(k, v); err == nil {
+				t.Errorf("Set(%s, %#v) should fail", k, v)
+			}
+		}
+	}
+	if got, want := s.Options()[1], "1m0s"; got != want {
+		t.Errorf("   got %v", got)
+		t.Errorf("  want %v", want)
+	}
+
+	now := time.Now()
+	if !s.Enabled(&Recorder{Source: "a.go", Line: 1, Message: "m1", Created: now}) {
+		t.Errorf("First recorder should be accepted")
+	}
+	if s.Enabled(&Recorder{Source: "a.go", Line: 1, Message: "m2", Created: now}) {
+		t.Errorf("Same caller should be sampled")
+	}
+	if !s.Enabled(&Recorder{Source: "a.go", Line: 2, Message: "m2", Created: now}) {
+		t.Errorf("Other caller should not be sampled")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Pending is implemented by asynchronous appenders, which report the
+// number of queued log recorders which are not written yet.
+type Pending interface {
+	Pending() int
+}
+
+// Abandoned is an appender which is not closed before the deadline.
+type Abandoned struct {
+	Appender string // the name of the appender. See Filter.SetErrorHandler
+	Records  int    // the number of queued log recorders, or -1 if unknown
+}
+
+// ShutdownError reports the appenders which are not closed before the
+// deadline, and the number of log recorders abandoned.
+type ShutdownError struct {
+	Err       error // the error of the context
+	Abandoned []Abandoned
+}
+
+// Records returns the number of log recorders abandoned, excluding the
+// appenders which do not report.
+func (e *ShutdownError) Records() (n int) {
+	for _, a := range e.Abandoned {
+		if a.Records > 0 {
+			n += a.Records
+		}
+	}
+	return
+}
+
+func (e *ShutdownError) Error() string {
+	names := make([]string, 0, len(e.Abandoned))
+	for _, a := range e.Abandoned {
+		if a.Records < 0 {
+			names = append(names, a.Appender)
+		} else {
+			names = append(names, fmt.Sprintf("%s (%d)", a.Appender, a.Records))
+		}
+	}
+	return fmt.Sprintf("shutdown: %v: %d appenders not drained, %d log recorders abandoned: %s",
+		e.Err, len(e.Abandoned), e.Records(), strings.Join(names, ", "))
+}
+
+// Unwrap returns the error of the context.
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// Shutdown writes the pending summary of the suppressor, then closes all
+// appenders concurrently, and waits until they are closed or the context
+// is done. Appenders which are not closed in time keep closing in the
+// background, and are reported by *ShutdownError. If the filter can not
+// be detached in time, such as a dispatching blocked by a full queue, the
+// filter itself is reported.
+//
+// Notice: Shutdown() removes all appenders from the filter.
+func (f *Filter) Shutdown(ctx context.Context) error {
+	detached := make(chan []Appender, 1)
+	go func() {
+		detached <- f.detach()
+	}()
+
+	var apps []Appender
+	select {
+	case apps = <-detached:
+	case <-ctx.Done():
+		go func() {
+			for _, a := range <-detached {
+				if a != nil {
+					a.Close()
+				}
+			}
+		}()
+		return &ShutdownError{
+			Err:       ctx.Err(),
+			Abandoned: []Abandoned{{Appender: f.Name, Records: -1}},
+		}
+	}
+
+	dones := make([]chan struct{}, len(apps))
+	for i, a := range apps {
+		if a == nil {
+			continue
+		}
+		dones[i] = make(chan struct{})
+		go func(a Appender, done chan struct{}) {
+			a.Close()
+			close(done)
+		}(a, dones[i])
+	}
+
+	var (
+		abandoned []Abandoned
+		expired   bool
+	)
+	for i, done := range dones {
+		if done == nil {
+			continue
+		}
+		if !expired {
+			select {
+			case <-done:
+				continue
+			case <-ctx.Done():
+				expired = true
+			}
+		}
+		select {
+		case <-done:
+			continue
+		default:
+		}
+		a := Abandoned{Appender: f.appName(i), Records: -1}
+		if p, ok := apps[i].(Pending); ok {
+			a.Records = p.Pending()
+		}
+		abandoned = append(abandoned, a)
+	}
+	if abandoned != nil {
+		return &ShutdownError{Err: ctx.Err(), Abandoned: abandoned}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stuckAppender struct {
+	nopAppender
+	release chan struct{}
+}
+
+func (a *stuckAppender) Close()       { <-a.release }
+func (a *stuckAppender) Pending() int { return 7 }
+
+func TestFilterShutdown(t *testing.T) {
+	stuck := &stuckAppender{release: make(chan struct{})}
+	defer close(stuck.release)
+
+	f := &Filter{Name: "socket", Apps: []Appender{&nopAppender{}, stuck}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := f.Shutdown(ctx)
+	var se *ShutdownError
+	if !errors.As(err, &se) {
+		t.Fatalf("Shutdown should fail, found %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown error should wrap the context error, found %v", se.Err)
+	}
+	if got, want := se.Abandoned, []Abandoned{{"socket.1", 7}}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("   got %v", got)
+		t.Errorf("  want %v", want)
+	}
+	if len(f.Apps) != 0 {
+		t.Errorf("Shutdown should remove all appenders")
+	}
+
+	f = &Filter{Name: "file", Apps: []Appender{&nopAppender{}}}
+	if err := f.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown should succeed, found %v", err)
+	}
+}
+
+// blockedAppender blocks in Enabled, like a full queue with OverflowBlock.
+type blockedAppender struct {
+	nopAppender
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (a *blockedAppender) Enabled(r *Recorder) bool {
+	close(a.entered)
+	<-a.release
+	return false
+}
+
+func TestFilterShutdownBlocked(t *testing.T) {
+	blocked := &blockedAppender{entered: make(chan struct{}), release: make(chan struct{})}
+	defer close(blocked.release)
+
+	f := &Filter{Name: "socket", Apps: []Appender{blocked}}
+	go f.Dispatch(&Recorder{Message: "stuck"})
+	<-blocked.entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f.Shutdown(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Shutdown should fail with the context error, found %v", err)
+		}
+		var se *ShutdownError
+		if !errors.As(err, &se) || len(se.Abandoned) != 1 || se.Abandoned[0] != (Abandoned{"socket", -1}) {
+			t.Errorf("Shutdown should report the filter, found %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown should return after the deadline")
+	}
+}
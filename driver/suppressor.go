@@ -0,0 +1,177 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package driver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ccpaging/nxlog4go/cast"
+)
+
+// DefaultSuppressWindow is the default window of duplicate suppression.
+var DefaultSuppressWindow = 30 * time.Second
+
+// Suppressor collapses consecutive identical log recorders, which have the
+// same level, prefix, message and source, within a window into one recorder
+// plus a synthetic "last message repeated N times" recorder, like syslogd.
+//
+// The summary is emitted when a different recorder arrives, when the window
+// is over, or when the suppressor is flushed. A suppressor of a filter
+// dispatches the summary by itself when the window is over, through the
+// processors of the filter.
+type Suppressor struct {
+	mu     sync.Mutex
+	window time.Duration
+
+	last  Recorder    // the last recorder written, without values
+	since time.Time   // the time of the last recorder written
+	at    time.Time   // the time of the last repeat
+	count int         // the number of repeats
+	timer *time.Timer // fires when the window of repeats is over
+	owner *Filter     // dispatches the summary when the timer fires
+}
+
+// NewSuppressor creates a suppressor with the window.
+// A window <= 0 means DefaultSuppressWindow.
+func NewSuppressor(window time.Duration) *Suppressor {
+	if window <= 0 {
+		window = DefaultSuppressWindow
+	}
+	return &Suppressor{window: window}
+}
+
+func (s *Suppressor) same(r *Recorder) bool {
+	if s.last.Level != r.Level || s.last.Message != r.Message || s.last.Prefix != r.Prefix {
+		return false
+	}
+	source, line := r.Caller()
+	return s.last.Source == source && s.last.Line == line
+}
+
+// summary returns the synthetic recorder of repeats, and resets the count.
+func (s *Suppressor) summary() *Recorder {
+	if s.count <= 0 {
+		return nil
+	}
+	r := &Recorder{
+		Prefix:  s.last.Prefix,
+		Source:  s.last.Source,
+		Line:    s.last.Line,
+		Level:   s.last.Level,
+		Message: fmt.Sprintf("last message repeated %d times", s.count),
+		Created: s.at,
+	}
+	s.count = 0
+	return r
+}
+
+// Suppress checks the recorder. It returns true if the recorder is a
+// repeat which should be dropped. If there are pending repeats which
+// should be reported before the recorder, it returns the summary also.
+func (s *Suppressor) Suppress(r *Recorder) (summary *Recorder, drop bool) {
+	return s.suppress(r, nil)
+}
+
+// suppress is Suppress of the filter, which dispatches the summary when
+// the window is over.
+func (s *Suppressor) suppress(r *Recorder, owner *Filter) (summary *Recorder, drop bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.since.IsZero() && s.same(r) && r.Created.Sub(s.since) < s.window {
+		s.count++
+		s.at = r.Created
+		if s.count == 1 && owner != nil {
+			s.owner = owner
+			s.startTimer(time.Until(s.since.Add(s.window)))
+		}
+		return nil, true
+	}
+
+	summary = s.summary()
+	source, line := r.Caller()
+	s.last = Recorder{
+		Prefix:  r.Prefix,
+		Source:  source,
+		Line:    line,
+		Level:   r.Level,
+		Message: r.Message,
+	}
+	s.since = r.Created
+	return summary, false
+}
+
+func (s *Suppressor) startTimer(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	if s.timer == nil {
+		s.timer = time.AfterFunc(d, s.expire)
+	} else {
+		s.timer.Reset(d)
+	}
+}
+
+// expire dispatches the summary of pending repeats by the filter, when the
+// window is over.
+func (s *Suppressor) expire() {
+	s.mu.Lock()
+	summary, f := s.summary(), s.owner
+	s.mu.Unlock()
+
+	if summary != nil && f != nil {
+		f.mu.RLock()
+		defer f.mu.RUnlock()
+		f.dispatchSummary(summary)
+	}
+}
+
+// Needs returns AttrCaller, since repeats are compared by the caller.
+func (s *Suppressor) Needs() Attrs {
+	return AttrCaller
+}
+
+// Flush returns the summary of pending repeats, or nil if there is none.
+func (s *Suppressor) Flush() *Recorder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary()
+}
+
+// Options returns the current name-value pair options. See Set.
+func (s *Suppressor) Options() []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return []interface{}{"suppressWindow", s.window.String()}
+}
+
+// Set sets name-value option with:
+//
+//	suppressWindow - The window of duplicate suppression, such as "30s"
+//
+// Return error.
+func (s *Suppressor) Set(k string, v interface{}) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch k {
+	case "suppressWindow":
+		var str string
+		if str, err = cast.ToString(v); err == nil {
+			var d time.Duration
+			if d, err = time.ParseDuration(str); err == nil && d > 0 {
+				s.window = d
+			}
+		} else {
+			var n int64
+			if n, err = cast.ToSeconds(v); err == nil && n > 0 {
+				s.window = time.Duration(n) * time.Second
+			}
+		}
+	default:
+		return fmt.Errorf("unknown option name %s, value %#v of type %T", k, v, v)
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package driver
+
+import (
+	"testing"
+	"time"
+)
+
+type recordApp struct {
+	nopAppender
+	recs []*Recorder
+}
+
+func (a *recordApp) Enabled(r *Recorder) bool {
+	a.recs = append(a.recs, r)
+	return false
+}
+
+func TestFilterSuppressor(t *testing.T) {
+	app := &recordApp{}
+	f := &Filter{
+		Name:       "test",
+		Apps:       []Appender{app},
+		Suppressor: NewSuppressor(time.Minute),
+	}
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		f.Dispatch(&Recorder{Level: 6, Message: "down", Created: now.Add(time.Duration(i) * time.Second)})
+	}
+	f.Dispatch(&Recorder{Level: 6, Message: "up", Created: now.Add(5 * time.Second)})
+	f.Dispatch(&Recorder{Level: 6, Message: "up", Created: now.Add(2 * time.Minute)})
+
+	want := []string{"down", "last message repeated 4 times", "up", "up"}
+	if len(app.recs) != len(want) {
+		t.Fatalf("got %d recorders, want %d", len(app.recs), len(want))
+	}
+	for i, r := range app.recs {
+		if r.Message != want[i] {
+			t.Errorf("   got %q", r.Message)
+			t.Errorf("  want %q", want[i])
+		}
+	}
+	if got, want := app.recs[1].Created, now.Add(4*time.Second); !got.Equal(want) {
+		t.Errorf("   got %v", got)
+		t.Errorf("  want %v", want)
+	}
+
+	f.Dispatch(&Recorder{Level: 6, Message: "up", Created: now.Add(2*time.Minute + time.Second)})
+	f.Close()
+	if got, want := app.recs[len(app.recs)-1].Message, "last message repeated 1 times"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+// syncApp sends recorders to a channel, since summaries are dispatched by
+// the timer of the suppressor.
+type syncApp struct {
+	nopAppender
+	recs chan *Recorder
+}
+
+func (a *syncApp) Enabled(r *Recorder) bool {
+	a.recs <- r.Clone()
+	return false
+}
+
+func TestFilterSuppressorExpire(t *testing.T) {
+	app := &syncApp{recs: make(chan *Recorder, 8)}
+	f := &Filter{
+		Name:       "test",
+		Apps:       []Appender{app},
+		Suppressor: NewSuppressor(20 * time.Millisecond),
+		Processors: Processors{NewFieldsProcessor(String("host", "db1"))},
+	}
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		f.Dispatch(&Recorder{Level: 6, Message: "down", Created: now})
+	}
+	if r := <-app.recs; r.Message != "down" {
+		t.Fatalf("got %q, want %q", r.Message, "down")
+	}
+
+	// The burst ends, and no more recorders follow
+	select {
+	case r := <-app.recs:
+		if got, want := r.Message, "last message repeated 2 times"; got != want {
+			t.Errorf("   got %q", got)
+			t.Errorf("  want %q", want)
+		}
+		if len(r.Typed) != 1 || r.Typed[0].Key != "host" {
+			t.Errorf("Summary should be processed, got fields %v", r.Typed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Summary should be dispatched when the window is over")
+	}
+
+	f.Close()
+	select {
+	case r := <-app.recs:
+		t.Errorf("Unexpected recorder %q", r.Message)
+	default:
+	}
+}
@@ -4,7 +4,7 @@
 package nxlog4go
 
 import (
-	"runtime"
+	"context"
 	"time"
 
 	"github.com/ccpaging/nxlog4go/driver"
@@ -17,6 +17,7 @@ import (
 type Entry struct {
 	rec *driver.Recorder
 	log *Logger
+	ctx context.Context
 
 	addSkip int
 }
@@ -31,6 +32,17 @@ func NewEntry(l *Logger) *Entry {
 	}
 }
 
+// clone returns a copy of the entry. Fields added to the copy don't affect
+// the entry, and vice versa.
+func (e *Entry) clone() *Entry {
+	c := *e
+	rec := *e.rec
+	rec.Values = rec.Values[:len(rec.Values):len(rec.Values)]
+	rec.Typed = rec.Typed[:len(rec.Typed):len(rec.Typed)]
+	c.rec = &rec
+	return &c
+}
+
 // SetPrefix sets the output prefix for the entry.
 func (e *Entry) SetPrefix(prefix string) *Entry {
 	e.rec.Prefix = prefix
@@ -52,12 +64,26 @@ func (e *Entry) With(args ...interface{}) *Entry {
 	return e
 }
 
+// WithFields appends typed fields to the log entry. Typed fields are
+// stored and encoded without boxing. See Field.
+func (e *Entry) WithFields(fields ...Field) *Entry {
+	e.rec.WithFields(fields...)
+	return e
+}
+
 // WithMore appends name-value pairs to the log entry.
 func (e *Entry) WithMore(args ...interface{}) *Entry {
 	e.rec.WithMore(args...)
 	return e
 }
 
+// WithContext sets the context of the entry. Values are pulled out of the
+// context by the registered context extractors at log time.
+func (e *Entry) WithContext(ctx context.Context) *Entry {
+	e.ctx = ctx
+	return e
+}
+
 // Log sends a log message with level, and message.
 // Call depth:
 //  2 - Where calling the wrapper of entry.Log(...)
@@ -68,23 +94,24 @@ func (e *Entry) Log(calldepth int, level int, arg0 interface{}, args ...interfac
 	if !l.enabled(level) {
 		return
 	}
-	r := &driver.Recorder{
-		Prefix:  e.rec.Prefix,
-		Level:   level,
-		Message: driver.ArgsToString(arg0),
-		Created: time.Now(),
-	}
+	r := driver.NewRecorder()
+	r.Prefix = e.rec.Prefix
+	r.Level = level
+	r.Message = driver.ArgsToString(arg0)
+	r.Created = time.Now()
 	r.With(e.rec.Values...)
+	r.Typed = e.rec.Typed[:len(e.rec.Typed):len(e.rec.Typed)]
 	r.WithMore(args...)
+	if e.ctx != nil {
+		r.WithMore(contextValues(e.ctx)...)
+	}
 
-	if l.caller {
-		// Determine caller func - it's expensive.
-		_, r.Source, r.Line, _ = runtime.Caller(calldepth + e.addSkip)
-	} else {
-		r.Source, r.Line = "", 0
+	if l.caller && l.needs()&driver.AttrCaller != 0 {
+		r.PC = callerPC(calldepth + e.addSkip)
 	}
 
 	l.Dispatch(r)
+	r.Release()
 }
 
 // Finest logs a message at the finest log level.
@@ -144,7 +171,9 @@ func (e *Entry) Error(arg0 interface{}, args ...interface{}) {
 
 // Critical logs a message at the critical log level and returns the formatted error,
 // See Warn for an explanation of the performance and Debug for an explanation
-// of the parameters.
+// of the parameters. The logger is flushed by Sync after logging, which
+// waits at most the "flushTimeout".
 func (e *Entry) Critical(arg0 interface{}, args ...interface{}) {
 	e.Log(2, CRITICAL, arg0, args...)
+	e.log.Sync()
 }
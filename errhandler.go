@@ -0,0 +1,56 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// SetErrorHandler sets the error handler of the logger, which handles the
+// errors of the output writer, as appender "stdout", and of the filters and
+// appenders of the logger, overriding the handlers set before. Filters
+// attached later use it too, unless they have their own handler.
+// See driver.ErrorHandler.
+//
+// A named logger without error handler uses the handler of its parent.
+// Without any error handler, errors of the built-in appenders are only
+// logged by the internal logger. See GetLogLog.
+func (l *Logger) SetErrorHandler(h driver.ErrorHandler) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.errHandler.Store(handlerBox{h})
+	for _, f := range l.filters.load() {
+		if f != nil {
+			f.SetErrorHandler(h)
+		}
+	}
+	return l
+}
+
+// ErrorHandler returns the effective error handler of the logger, or nil.
+func (l *Logger) ErrorHandler() driver.ErrorHandler {
+	return l.errorHandler()
+}
+
+// handlerBox boxes an error handler, which may be nil, for atomic.Value.
+type handlerBox struct {
+	h driver.ErrorHandler
+}
+
+// ownErrorHandler returns the error handler of the logger, or nil.
+func (l *Logger) ownErrorHandler() handlerBox {
+	eh, _ := l.errHandler.Load().(handlerBox)
+	return eh
+}
+
+// errorHandler returns the error handler of the logger, or of the nearest
+// ancestor which has one.
+func (l *Logger) errorHandler() driver.ErrorHandler {
+	for p := l; p != nil; p = p.parent {
+		if h := p.ownErrorHandler().h; h != nil {
+			return h
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestLoggerErrorHandler(t *testing.T) {
+	counter := driver.NewCountingErrorHandler(nil)
+	l := NewLogger(INFO).SetOutput(failWriter{}).SetErrorHandler(counter)
+
+	l.Named("db").Info("lost")
+	if got, want := counter.Count(stdfName, "write"), uint64(1); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+
+	l.AddFilter("app", INFO, &closeApp{buf: new(bytes.Buffer)})
+	if h := l.Filters()["app"].ErrorHandler; h != counter {
+		t.Errorf("Attached filter should use the error handler of the logger")
+	}
+
+	l.SetErrorHandler(nil)
+	l.Info("lost")
+	if got, want := counter.Total(), uint64(1); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// FileRefPrefix is the prefix of a value which refers to a file, such as
+// "@file:/run/secrets/token". The value is replaced by the content of the
+// file, without trailing newlines.
+const FileRefPrefix = "@file:"
+
+// expandVars replaces ${VAR} and ${VAR:-default} in the string with the
+// values of environment variables. It returns the names of unset variables
+// without default, which are replaced with empty strings.
+func expandVars(s string) (string, []string) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var (
+		b          strings.Builder
+		unresolved []string
+	)
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(s[i+2:], '}')
+		if j < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+
+		name, def, hasDef := s[i+2:i+2+j], "", false
+		if k := strings.Index(name, ":-"); k >= 0 {
+			name, def, hasDef = name[:k], name[k+2:], true
+		}
+		if v, ok := os.LookupEnv(name); ok && (v != "" || !hasDef) {
+			b.WriteString(v)
+		} else if hasDef {
+			b.WriteString(def)
+		} else {
+			unresolved = append(unresolved, name)
+		}
+		s = s[i+2+j+1:]
+	}
+	b.WriteString(s)
+	return b.String(), unresolved
+}
+
+// expandValue expands variables in the value, then reads the file if the
+// value is a file reference.
+func expandValue(s string) (string, []error) {
+	var errs []error
+
+	s, unresolved := expandVars(s)
+	for _, name := range unresolved {
+		errs = append(errs, fmt.Errorf("unresolved variable ${%s}", name))
+	}
+
+	if v := strings.TrimSpace(s); strings.HasPrefix(v, FileRefPrefix) {
+		path := v[len(FileRefPrefix):]
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", append(errs, err)
+		}
+		s = strings.TrimRight(string(buf), "\r\n")
+	}
+	return s, errs
+}
+
+// expand returns a copy of the configuration, whose Dsn, Level, property
+// and processor property values are expanded. See expandValue.
+//
+// A property, processor property or logger level which fails to expand is
+// skipped. A filter whose Dsn or Level fails to expand is marked as
+// unresolved, which is skipped by loading, and is kept as is by reloading.
+// The errors are reported.
+func (lc *LoggerConfig) expand() (c *LoggerConfig, errs []error) {
+	c = lc.clone()
+
+	report := func(where string, e []error) bool {
+		for _, err := range e {
+			errs = append(errs, fmt.Errorf("Warn: Expand %s. %v. Skipped", where, err))
+		}
+		return len(e) == 0
+	}
+
+	var e []error
+	for _, fc := range c.Filters {
+		fc.Dsn, e = expandValue(fc.Dsn)
+		resolved := report(fmt.Sprintf("filter [%s] dsn", fc.Tag), e)
+		fc.Level, e = expandValue(fc.Level)
+		if !report(fmt.Sprintf("filter [%s] level", fc.Tag), e) || !resolved {
+			fc.unresolved = true
+			continue
+		}
+		props := fc.Properties[:0]
+		for _, prop := range fc.Properties {
+			prop.Value, e = expandValue(prop.Value)
+			if report(fmt.Sprintf("filter [%s] property [%s]", fc.Tag, prop.Name), e) {
+				props = append(props, prop)
+			}
+		}
+		fc.Properties = props
+		errs = append(errs, expandProcessors(fmt.Sprintf("filter [%s] processor", fc.Tag), fc.Processors)...)
+	}
+	errs = append(errs, expandProcessors("processor", c.Processors)...)
+	loggers := c.Loggers[:0]
+	for _, nv := range c.Loggers {
+		nv.Value, e = expandValue(nv.Value)
+		if report(fmt.Sprintf("logger [%s] level", nv.Name), e) {
+			loggers = append(loggers, nv)
+		}
+	}
+	c.Loggers = loggers
+	return
+}
+
+func expandProcessors(where string, pcs []*ProcessorConfig) (errs []error) {
+	var e []error
+	for _, pc := range pcs {
+		props := pc.Properties[:0]
+		for _, prop := range pc.Properties {
+			prop.Value, e = expandValue(prop.Value)
+			for _, err := range e {
+				errs = append(errs, fmt.Errorf("Warn: Expand %s [%s] property [%s]. %v. Skipped", where, pc.Type, prop.Name, err))
+			}
+			if len(e) == 0 {
+				props = append(props, prop)
+			}
+		}
+		pc.Properties = props
+	}
+	return
+}
@@ -0,0 +1,143 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExpandVars(t *testing.T) {
+	os.Setenv("NXLOG4GO_SET", "set")
+	os.Setenv("NXLOG4GO_EMPTY", "")
+	defer os.Unsetenv("NXLOG4GO_SET")
+	defer os.Unsetenv("NXLOG4GO_EMPTY")
+
+	for _, tc := range []struct {
+		in, want   string
+		unresolved int
+	}{
+		{"plain", "plain", 0},
+		{"${NXLOG4GO_SET}", "set", 0},
+		{"a/${NXLOG4GO_SET}/b/${NXLOG4GO_SET:-x}", "a/set/b/set", 0},
+		{"${NXLOG4GO_EMPTY:-default}", "default", 0},
+		{"${NXLOG4GO_EMPTY}", "", 0},
+		{"${NXLOG4GO_UNSET:-}", "", 0},
+		{"[${NXLOG4GO_UNSET}]", "[]", 1},
+		{"${unclosed", "${unclosed", 0},
+	} {
+		got, unresolved := expandVars(tc.in)
+		if got != tc.want || len(unresolved) != tc.unresolved {
+			t.Errorf("%q: got %q %v, want %q", tc.in, got, unresolved, tc.want)
+		}
+	}
+}
+
+func TestLoadConfigurationExpand(t *testing.T) {
+	fd, err := ioutil.TempFile("", "nxlog4go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fd.Name())
+	fd.WriteString("secret\n")
+	fd.Close()
+
+	os.Setenv("NXLOG4GO_SECRET", fd.Name())
+	defer os.Unsetenv("NXLOG4GO_SECRET")
+
+	l := NewLogger(INFO)
+	errs := l.LoadConfiguration(&LoggerConfig{
+		Filters: []*FilterConfig{{
+			Enabled: "true",
+			Type:    "stdout",
+			Level:   "${NXLOG4GO_LEVEL:-DEBUG}",
+			Properties: []NameValue{
+				{Name: "prefix", Value: "@file:${NXLOG4GO_SECRET}"},
+				{Name: "format", Value: "${NXLOG4GO_FORMAT}"},
+			},
+		}},
+	})
+
+	if got, want := l.Level(), DEBUG; got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	if got, want := l.Prefix(), "secret"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+	// The secret is not kept by the logger
+	if got, want := l.config.Filters[0].Properties[0].Value, "@file:${NXLOG4GO_SECRET}"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+	l.ReloadConfiguration(l.config)
+	if got, want := l.config.Filters[0].Properties[0].Value, "@file:${NXLOG4GO_SECRET}"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	found := false
+	for _, err := range errs {
+		if strings.Contains(err.Error(), "${NXLOG4GO_FORMAT}") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Unresolved variable should be reported, found %v", errs)
+	}
+
+	// The property is skipped, not set to an empty string
+	l.LoadConfiguration(&LoggerConfig{
+		Filters: []*FilterConfig{{
+			Enabled: "true",
+			Type:    "stdout",
+			Level:   "DEBUG",
+			Properties: []NameValue{
+				{Name: "prefix", Value: "${NXLOG4GO_PREFIX}"},
+			},
+		}},
+	})
+	if got, want := l.Prefix(), "secret"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestExpandSkipUnresolved(t *testing.T) {
+	lc := &LoggerConfig{
+		Filters: []*FilterConfig{{
+			Tag:  "file",
+			Type: "file",
+			Dsn:  "${NXLOG4GO_UNSET}/app.log",
+		}, {
+			Tag:   "console",
+			Type:  "console",
+			Level: "INFO",
+			Properties: []NameValue{
+				{Name: "format", Value: "${NXLOG4GO_UNSET}"},
+				{Name: "color", Value: "true"},
+			},
+		}},
+		Loggers: []NameValue{{Name: "db", Value: "${NXLOG4GO_UNSET}"}},
+	}
+
+	c, errs := lc.expand()
+	if len(errs) != 3 {
+		t.Errorf("Expected 3 errors, found %v", errs)
+	}
+	if len(c.Filters) != 2 || !c.Filters[0].unresolved || c.Filters[1].unresolved {
+		t.Fatalf("Filter with unresolved dsn should be marked")
+	}
+	if props := c.Filters[1].Properties; len(props) != 1 || props[0].Name != "color" {
+		t.Errorf("Property with unresolved value should be skipped, found %v", props)
+	}
+	if len(c.Loggers) != 0 {
+		t.Errorf("Logger with unresolved level should be skipped, found %v", c.Loggers)
+	}
+	if len(lc.Filters) != 2 || len(lc.Filters[1].Properties) != 2 {
+		t.Errorf("The configuration should not be changed")
+	}
+}
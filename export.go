@@ -0,0 +1,168 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ccpaging/nxlog4go/cast"
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// toNameValues converts name-value pairs to properties.
+func toNameValues(pairs []interface{}) (nvs []NameValue) {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		k, ok := pairs[i].(string)
+		if !ok {
+			continue
+		}
+		v, err := cast.ToString(pairs[i+1])
+		if err != nil {
+			v = fmt.Sprint(pairs[i+1])
+		}
+		nvs = append(nvs, NameValue{Name: k, Value: v})
+	}
+	return
+}
+
+// optionsOf returns the options of an appender, a layout or an enabler.
+func optionsOf(v interface{}) []interface{} {
+	if o, ok := v.(driver.Optioner); ok {
+		return o.Options()
+	}
+	return nil
+}
+
+// processorConfigs returns the configurations of the processors. Processors
+// of unregistered types are skipped, since they can not be loaded.
+func processorConfigs(ps driver.Processors) (pcs []*ProcessorConfig) {
+	for _, p := range ps {
+		name := driver.ProcessorNameOf(p)
+		if name == "" {
+			continue
+		}
+		pcs = append(pcs, &ProcessorConfig{
+			Type:       name,
+			Properties: toNameValues(optionsOf(p)),
+		})
+	}
+	return
+}
+
+// loggerConfig returns the configuration of the standard output, or the
+// internal logger.
+func (l *Logger) loggerConfig(typ string) *FilterConfig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	props := []interface{}{"prefix", l.prefix, "caller", l.caller}
+	if l.flushTimeout > 0 {
+		props = append(props, "flushTimeout", l.flushTimeout.String())
+	}
+	props = append(props, optionsOf(l.stdf.lo)...)
+	return &FilterConfig{
+		Enabled:    fmt.Sprint(l.stdf.enb),
+		Tag:        typ,
+		Type:       typ,
+		Level:      levelName(l.stdf.level.Level()),
+		Properties: toNameValues(props),
+	}
+}
+
+// filterConfig returns the configurations of the filter, one for each
+// appender. The tag of the ith appender, i > 0, is "<name>.<i>".
+func filterConfig(f *driver.Filter) (fcs []*FilterConfig) {
+	f.View(func(f *driver.Filter) {
+		fcs = filterConfigLocked(f)
+	})
+	return
+}
+
+func filterConfigLocked(f *driver.Filter) (fcs []*FilterConfig) {
+	var props []interface{}
+	level := ""
+	for e := interface{}(f.Enabler); e != nil; {
+		if lv, ok := e.(driver.Leveler); ok {
+			level = levelName(lv.Level())
+			break
+		}
+		props = append(props, optionsOf(e)...)
+		u, ok := e.(driver.Unwrapper)
+		if !ok {
+			break
+		}
+		e = u.Unwrap()
+	}
+	if f.Suppressor != nil {
+		props = append(props, f.Suppressor.Options()...)
+	}
+	pcs := processorConfigs(f.Processors)
+
+	for i, app := range f.Apps {
+		if app == nil {
+			continue
+		}
+		fc := &FilterConfig{
+			Enabled:    "true",
+			Tag:        f.Name,
+			Type:       driver.NameOf(app),
+			Level:      level,
+			Properties: toNameValues(append(optionsOf(app), props...)),
+			Processors: pcs,
+		}
+		if i > 0 {
+			fc.Tag = fmt.Sprintf("%s.%d", f.Name, i)
+		}
+		if d, ok := app.(interface{ Dsn() string }); ok {
+			fc.Dsn = d.Dsn()
+		}
+		fcs = append(fcs, fc)
+	}
+	return
+}
+
+// Configuration exports the effective configuration of the logger, which
+// includes the standard output, the internal logger if it exists, filters
+// sorted by name, the levels of named loggers, and the processors of the
+// logger and of filters. Appenders, layouts, enablers and processors report
+// their options by driver.Optioner.
+//
+// The result can be marshaled as XML or JSON, and loaded back by
+// LoadConfiguration. A filter configuration has one appender, so a filter
+// with several appenders is exported as several filters, "<name>" and
+// "<name>.<i>", which are loaded back as separated filters.
+func (l *Logger) Configuration() *LoggerConfig {
+	lc := new(LoggerConfig)
+	lc.Processors = processorConfigs(l.Processors())
+	lc.Filters = append(lc.Filters, l.loggerConfig(stdfName))
+	if loglog != nil {
+		lc.Filters = append(lc.Filters, loglog.loggerConfig("loglog"))
+	}
+
+	l.mu.Lock()
+	filters := l.outputs()
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if f := filters[name]; f != nil {
+			lc.Filters = append(lc.Filters, filterConfig(f)...)
+		}
+	}
+	l.mu.Unlock()
+
+	l.walk(func(c *Logger) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if lv := c.ownLevel(); c.parent != nil && lv != nil {
+			lc.Loggers = append(lc.Loggers, NameValue{Name: c.name, Value: levelName(lv.Level())})
+		}
+	})
+	sort.Slice(lc.Loggers, func(i, j int) bool {
+		return lc.Loggers[i].Name < lc.Loggers[j].Name
+	})
+	return lc
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	l4g "github.com/ccpaging/nxlog4go"
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+func TestConfigurationRoundTrip(t *testing.T) {
+	lc := &l4g.LoggerConfig{
+		Filters: []*l4g.FilterConfig{
+			{Enabled: "true", Type: "stdout", Level: "WARN", Properties: []l4g.NameValue{
+				{Name: "format", Value: "%L %M"},
+			}},
+			{Enabled: "true", Tag: "con", Type: "console", Level: "DEBUG", Properties: []l4g.NameValue{
+				{Name: "color", Value: "true"},
+				{Name: "timeEncoder", Value: "hms.us"},
+				{Name: "sampleFirst", Value: "10"},
+				{Name: "suppressWindow", Value: "1m"},
+			}, Processors: []*l4g.ProcessorConfig{
+				{Type: "fields", Properties: []l4g.NameValue{{Name: "version", Value: "1.2"}}},
+			}},
+			{Enabled: "true", Tag: "log", Type: "file", Dsn: "_export.log", Level: "INFO", Properties: []l4g.NameValue{
+				{Name: "rotate", Value: "3"},
+				{Name: "cycle", Value: "1h"},
+			}},
+			{Enabled: "true", Tag: "udp", Type: "socket", Dsn: "udp://127.0.0.1:12124", Level: "ERROR"},
+		},
+		Loggers: []l4g.NameValue{{Name: "db.pool", Value: "DEBUG"}},
+		Processors: []*l4g.ProcessorConfig{
+			{Type: "env", Properties: []l4g.NameValue{{Name: "pod", Value: "POD_NAME"}}},
+		},
+	}
+
+	log := l4g.NewLogger(l4g.INFO)
+	log.LoadConfiguration(lc)
+	defer log.Close()
+
+	want := log.Configuration()
+	types := ""
+	for _, fc := range want.Filters {
+		if fc.Type != "loglog" {
+			types += fc.Type + " "
+		}
+	}
+	if types != "stdout console file socket " {
+		t.Fatalf("Unexpected filters %q", types)
+	}
+	if got := want.Loggers; len(got) != 1 || got[0].Name != "db.pool" || got[0].Value != "DEBUG" {
+		t.Errorf("Unexpected loggers %v", got)
+	}
+	if got := want.Processors; len(got) != 1 || got[0].Type != "env" {
+		t.Errorf("Unexpected processors %v", got)
+	}
+	for _, fc := range want.Filters {
+		if fc.Type == "console" && (len(fc.Processors) != 1 || fc.Processors[0].Type != "fields") {
+			t.Errorf("Unexpected processors of filter %v", fc.Processors)
+		}
+	}
+
+	buf, err := xml.MarshalIndent(want, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromXML := new(l4g.LoggerConfig)
+	if err := xml.Unmarshal(buf, fromXML); err != nil {
+		t.Fatalf("Could not parse exported XML: %s\n%s", err, buf)
+	}
+
+	buf, err = json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON := new(l4g.LoggerConfig)
+	if err := json.Unmarshal(buf, fromJSON); err != nil {
+		t.Fatalf("Could not parse exported JSON: %s\n%s", err, buf)
+	}
+
+	for name, lc := range map[string]*l4g.LoggerConfig{"xml": fromXML, "json": fromJSON} {
+		clone := l4g.NewLogger(l4g.INFO)
+		clone.LoadConfiguration(lc)
+		got := clone.Configuration()
+		clone.Close()
+
+		got.XMLName, want.XMLName = xml.Name{}, xml.Name{}
+		if !reflect.DeepEqual(got, want) {
+			g, _ := json.MarshalIndent(got, "", "  ")
+			w, _ := json.MarshalIndent(want, "", "  ")
+			t.Errorf("%s round trip:\n   got %s\n  want %s", name, g, w)
+		}
+	}
+}
+
+func TestConfigurationSeveralAppenders(t *testing.T) {
+	log := l4g.NewLogger(l4g.INFO).SetOutput(nil)
+	defer log.Close()
+	a, _ := driver.Open("console", "")
+	b, _ := driver.Open("console", "")
+	log.Attach(&driver.Filter{Name: "multi", Enabler: driver.NewAtomicLevel(l4g.WARN),
+		Apps: []driver.Appender{a, b}})
+
+	want := log.Configuration()
+	tags := ""
+	for _, fc := range want.Filters {
+		if fc.Type == "console" {
+			tags += fc.Tag + " "
+		}
+	}
+	if tags != "multi multi.1 " {
+		t.Fatalf("Unexpected filters %q", tags)
+	}
+
+	// Loaded back as separated filters, which are exported the same
+	clone := l4g.NewLogger(l4g.INFO).SetOutput(nil)
+	defer clone.Close()
+	clone.LoadConfiguration(want)
+	if got := len(clone.Filters()); got != 2 {
+		t.Errorf("Loaded %d filters, want 2", got)
+	}
+	if got := clone.Configuration(); !reflect.DeepEqual(got, want) {
+		g, _ := json.MarshalIndent(got, "", "  ")
+		w, _ := json.MarshalIndent(want, "", "  ")
+		t.Errorf("Round trip:\n   got %s\n  want %s", g, w)
+	}
+}
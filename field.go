@@ -0,0 +1,33 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"time"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// Field is a typed name-value pair. See driver.Field.
+type Field = driver.Field
+
+// String constructs a field with the given key and string value.
+func String(key string, val string) Field { return driver.String(key, val) }
+
+// Int64 constructs a field with the given key and int64 value.
+func Int64(key string, val int64) Field { return driver.Int64(key, val) }
+
+// Duration constructs a field with the given key and time.Duration value.
+func Duration(key string, val time.Duration) Field { return driver.Duration(key, val) }
+
+// Time constructs a field with the given key and time.Time value.
+func Time(key string, val time.Time) Field { return driver.Time(key, val) }
+
+// Err constructs a field with the key "error" and the error value.
+func Err(err error) Field { return driver.Err(err) }
+
+// Bool constructs a field with the given key and bool value.
+func Bool(key string, val bool) Field { return driver.Bool(key, val) }
+
+// Any constructs a field with the given key and an arbitrary value.
+func Any(key string, val interface{}) Field { return driver.Any(key, val) }
@@ -0,0 +1,61 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/ccpaging/nxlog4go/patt"
+)
+
+func TestEntryWithTypedFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%L %M%F").SetOutput(buf)
+
+	l.With("k", "v").WithFields(String("s", "str"), Int64("n", 7), Bool("b", false)).Info("typed")
+	if got, want := buf.String(), "INFO typed k=v s=str n=7 b=false\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	buf.Reset()
+	l.SetLayout(patt.NewJSONLayout())
+	l.WithFields(String("s", "str"), Err(fmt.Errorf("boom"))).Info("typed")
+
+	um := &struct {
+		Message string
+		Fields  map[string]interface{}
+	}{}
+	if err := json.Unmarshal(buf.Bytes(), um); err != nil {
+		t.Fatalf("%q: %v", buf.Bytes(), err)
+	}
+	if got, want := um.Fields["s"], "str"; got != want {
+		t.Errorf("   got %v", got)
+		t.Errorf("  want %v", want)
+	}
+	if got, want := um.Fields["error"], "boom"; got != want {
+		t.Errorf("   got %v", got)
+		t.Errorf("  want %v", want)
+	}
+}
+
+func BenchmarkEntryWithArgs(b *testing.B) {
+	l := NewLogger(INFO).SetOptions("caller", false, "format", "%M%F").SetOutput(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.With("s", "str", "n", int64(i), "d", time.Second).Info("message")
+	}
+}
+
+func BenchmarkEntryWithFields(b *testing.B) {
+	l := NewLogger(INFO).SetOptions("caller", false, "format", "%M%F").SetOutput(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.WithFields(String("s", "str"), Int64("n", int64(i)), Duration("d", time.Second)).Info("message")
+	}
+}
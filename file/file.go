@@ -23,13 +23,18 @@ var (
 
 // Appender represents the log appender that sends output to a file
 type Appender struct {
-	mu       sync.Mutex            // ensures atomic writes; protects the following fields
-	rec      chan *driver.Recorder // entry channel
+	stats driver.StatsCounter // bytes written and write errors; first for alignment
+
+	mu       sync.Mutex         // ensures atomic writes; protects the following fields
+	queue    *driver.Queue      // entry queue
+	flush    chan chan struct{} // flush request channel
+	exit     chan struct{}      // closed when run returns
 	runOnce  sync.Once
 	waitExit *sync.WaitGroup
 
-	level  int
+	level  driver.AtomicLevel
 	layout driver.Layout // format entry for output
+	errs   driver.ErrorReporter
 
 	out    *RotateFile
 	rotate int // rolling number. -1, no rotate; 0, no backup; 1 ... n, backup n log files
@@ -63,7 +68,8 @@ func NewAppender(filename string, args ...interface{}) (*Appender, error) {
 	}
 
 	fa := &Appender{
-		rec: make(chan *driver.Recorder, 32),
+		queue: driver.NewQueue(driver.DefaultQueueCapacity, driver.OverflowBlock),
+		flush: make(chan chan struct{}),
 
 		layout: patt.NewLayout(""),
 
@@ -85,6 +91,16 @@ func (*Appender) Open(filename string, args ...interface{}) (driver.Appender, er
 	return NewAppender(filename, args...)
 }
 
+// Validate checks the name-value option on a new appender, which does not
+// open the file. See driver.Validator.
+func (*Appender) Validate(k string, v interface{}) error {
+	fa, err := NewAppender("")
+	if err != nil {
+		return err
+	}
+	return fa.Set(k, v)
+}
+
 // Layout returns the output layout for the appender.
 func (fa *Appender) Layout() driver.Layout {
 	fa.mu.Lock()
@@ -97,9 +113,65 @@ func (fa *Appender) SetLayout(layout driver.Layout) *Appender {
 	fa.mu.Lock()
 	defer fa.mu.Unlock()
 	fa.layout = layout
+	driver.InvalidateLevels()
 	return fa
 }
 
+// Needs returns the attributes used by the layout. See driver.AttrsNeeder.
+func (fa *Appender) Needs() driver.Attrs {
+	return driver.NeedsOf(fa.Layout())
+}
+
+// Level returns the output level of the appender.
+func (fa *Appender) Level() int {
+	return fa.level.Level()
+}
+
+// SetLevel sets the output level of the appender. It is safe while logging.
+func (fa *Appender) SetLevel(n int) {
+	fa.level.SetLevel(n)
+}
+
+// SetErrorHandler sets the handler of write and rotate errors.
+// See driver.ErrorHandlerSetter.
+func (fa *Appender) SetErrorHandler(name string, h driver.ErrorHandler) {
+	fa.errs.SetErrorHandler(name, h)
+}
+
+// Dsn returns the file name of the appender.
+func (fa *Appender) Dsn() string {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+	return fa.out.Name()
+}
+
+// Options returns the current name-value pair options of the appender
+// and its layout. See Set.
+func (fa *Appender) Options() []interface{} {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+
+	opts := []interface{}{
+		"level", l4g.Level(fa.level.Level()).Name(),
+		"maxsize", fa.out.Maxsize,
+		"maxlines", fa.out.Maxlines,
+		"rotate", fa.rotate,
+		"cycle", fmt.Sprintf("%ds", fa.cycle),
+		"delay", fmt.Sprintf("%ds", fa.delay),
+	}
+	if fa.out.Header != "" {
+		opts = append(opts, "head", fa.out.Header)
+	}
+	if fa.out.Footer != "" {
+		opts = append(opts, "foot", fa.out.Footer)
+	}
+	opts = append(opts, fa.queue.Options()...)
+	if o, ok := fa.layout.(driver.Optioner); ok {
+		opts = append(opts, o.Options()...)
+	}
+	return opts
+}
+
 // SetOptions sets name-value pair options.
 //
 // Return the appender.
@@ -114,14 +186,18 @@ func (fa *Appender) SetOptions(args ...interface{}) *Appender {
 // Enabled encodes log Recorder and output it.
 func (fa *Appender) Enabled(r *driver.Recorder) bool {
 	// r.Level < fa.level
-	if fa.level != 0 && r.Level < fa.level {
+	if driver.LevelBelow(r.Level, fa.level.Level()) {
 		return false
 	}
 
 	fa.runOnce.Do(func() {
 		fa.waitExit = &sync.WaitGroup{}
 		fa.waitExit.Add(1)
-		go fa.run(fa.waitExit)
+		exit := make(chan struct{})
+		fa.mu.Lock()
+		fa.exit = exit
+		fa.mu.Unlock()
+		go fa.run(fa.waitExit, exit)
 	})
 
 	if fa.waitExit == nil {
@@ -130,7 +206,7 @@ func (fa *Appender) Enabled(r *driver.Recorder) bool {
 		return false
 	}
 
-	fa.rec <- r
+	fa.queue.Put(r.Clone())
 	return false
 }
 
@@ -162,7 +238,70 @@ func newRotateTimer(cycle int64, delay int64) *time.Timer {
 	return time.NewTimer(d)
 }
 
-func (fa *Appender) run(waitExit *sync.WaitGroup) {
+func (fa *Appender) drain() {
+	for {
+		select {
+		case r, ok := <-fa.queue.C():
+			if !ok {
+				return
+			}
+			fa.output(r)
+			r.Release()
+		default:
+			fa.mu.Lock()
+			fa.out.Flush()
+			fa.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Pending returns the number of queued log recorders. See driver.Pending.
+func (fa *Appender) Pending() int {
+	return fa.queue.Len()
+}
+
+// Dropped returns the number of log recorders dropped when the queue
+// overflows. See driver.Dropper.
+func (fa *Appender) Dropped() uint64 {
+	return fa.queue.Dropped()
+}
+
+// Stats returns the output statistics. See driver.Statser.
+func (fa *Appender) Stats() driver.Stats {
+	s := fa.stats.Stats()
+	fa.mu.Lock()
+	s.Rotations = fa.out.Rotations()
+	fa.mu.Unlock()
+	return s
+}
+
+// Flush writes all queued log recorders. It blocks until done, or until
+// the appender is closed.
+func (fa *Appender) Flush() {
+	fa.mu.Lock()
+	exit := fa.exit
+	fa.mu.Unlock()
+
+	if exit != nil {
+		done := make(chan struct{})
+		select {
+		case fa.flush <- done:
+			<-done
+			return
+		case <-exit:
+			// Closed already
+		}
+	}
+	// Not running, or closed already
+	fa.mu.Lock()
+	fa.out.Flush()
+	fa.mu.Unlock()
+}
+
+func (fa *Appender) run(waitExit *sync.WaitGroup, exit chan struct{}) {
+	defer close(exit)
+
 	l4g.LogLogTrace("cycle %v", time.Duration(fa.cycle)*time.Second)
 	fa.doRotate()
 
@@ -174,12 +313,17 @@ func (fa *Appender) run(waitExit *sync.WaitGroup) {
 			t = newRotateTimer(fa.cycle, fa.delay)
 			fa.doRotate()
 
-		case r, ok := <-fa.rec:
+		case done := <-fa.flush:
+			fa.drain()
+			close(done)
+
+		case r, ok := <-fa.queue.C():
 			if !ok {
 				waitExit.Done()
 				return
 			}
 			fa.output(r)
+			r.Release()
 
 		case _, ok := <-fa.reset:
 			if !ok {
@@ -193,13 +337,14 @@ func (fa *Appender) run(waitExit *sync.WaitGroup) {
 
 func (fa *Appender) closeChannel() {
 	// notify closing. See run()
-	close(fa.rec)
+	fa.queue.Close()
 	// waiting for running channel closed
 	fa.waitExit.Wait()
 	fa.waitExit = nil
 	// drain channel
-	for left := range fa.rec {
+	for left := range fa.queue.C() {
 		fa.output(left)
+		left.Release()
 	}
 }
 
@@ -228,8 +373,13 @@ func (fa *Appender) output(r *driver.Recorder) {
 
 	fa.mu.Lock()
 	fa.layout.Encode(buf, r)
-	fa.out.Write(buf.Bytes())
+	n, err := fa.out.Write(buf.Bytes())
 	fa.mu.Unlock()
+	fa.stats.Wrote(n, err)
+
+	if err != nil {
+		fa.errs.Report("write", err)
+	}
 
 	if fa.cycle <= 0 {
 		// rotating on demand
@@ -243,9 +393,12 @@ func (fa *Appender) doRotate() {
 	}
 
 	fa.mu.Lock()
-	defer fa.mu.Unlock()
+	err := fa.out.Rotate(fa.rotate)
+	fa.mu.Unlock()
 
-	fa.out.Rotate(fa.rotate)
+	if err != nil {
+		fa.errs.Report("rotate", err)
+	}
 }
 
 func (fa *Appender) setFileOption(k string, v interface{}) (err error) {
@@ -306,6 +459,9 @@ func (fa *Appender) setCycleOption(k string, v interface{}) (err error) {
 
 // Set sets name-value option with:
 //  level    - The output level
+//  queue    - The capacity of the queue. The default is 32
+//  overflow - The overflow policy of the queue. "block" (default),
+//             "dropNewest", "dropOldest" or "timeout". See driver.Queue
 //  head     - The header of log file. May includes %D (date) and %T (time).
 //  foot     - The trailer of log file.
 //  maxsize  - Rotating while size > maxsize
@@ -336,7 +492,7 @@ func (fa *Appender) Set(k string, v interface{}) (err error) {
 	switch k {
 	case "level":
 		if n, err = l4g.Level(l4g.INFO).IntE(v); err == nil {
-			fa.level = n
+			fa.level.SetLevel(n)
 		}
 	case "filename", "head", "foot", "maxsize", "maxlines", "maxrecords":
 		err = fa.setFileOption(k, v)
@@ -362,6 +518,8 @@ func (fa *Appender) Set(k string, v interface{}) (err error) {
 		}
 	case "cycle", "delay", "clock":
 		err = fa.setCycleOption(k, v)
+	case "queue", "overflow", "overflowTimeout":
+		err = fa.queue.Set(k, v)
 	default:
 		return fa.layout.Set(k, v)
 	}
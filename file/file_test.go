@@ -3,6 +3,7 @@
 package file
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -214,3 +215,109 @@ BenchmarkCacheFileNotLogged-4            5239846               225 ns/op
 BenchmarkCacheFileUtilLog-4               666751              1776 ns/op
 BenchmarkCacheFileUtilNotLog-4           2850147               431 ns/op
 */
+
+func TestFileAppenderFlush(t *testing.T) {
+	w, _ := NewAppender(testLogFile)
+	if w == nil {
+		t.Fatalf("Invalid return: w should not be nil")
+	}
+	defer removeFile(t, testLogFile)
+
+	log := l4g.NewLogger(l4g.INFO).SetOutput(nil).Attach(newFilter(l4g.INFO, w))
+	for i := 0; i < 100; i++ {
+		log.Info("message %d", i)
+	}
+	if err := log.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flushed without closing
+	if contents, err := ioutil.ReadFile(testLogFile); err != nil {
+		t.Errorf("read(%q): %s", testLogFile, err)
+	} else if n := bytes.Count(contents, []byte("\n")); n != 100 {
+		t.Errorf("Expected 100 lines, found %d", n)
+	}
+	w.Close()
+}
+
+func TestFileAppenderQueue(t *testing.T) {
+	w, _ := NewAppender(testLogFile, "queue", 4, "overflow", "dropOldest")
+	if w == nil {
+		t.Fatalf("Invalid return: w should not be nil")
+	}
+	defer removeFile(t, testLogFile)
+
+	opts := w.Options()
+	for i := 0; i+1 < len(opts); i += 2 {
+		if opts[i] == "queue" && opts[i+1] != 4 {
+			t.Errorf("queue got %v, want 4", opts[i+1])
+		}
+		if opts[i] == "overflow" && opts[i+1] != "dropOldest" {
+			t.Errorf("overflow got %v, want dropOldest", opts[i+1])
+		}
+	}
+
+	log := l4g.NewLogger(l4g.INFO).SetOutput(nil).Attach(newFilter(l4g.INFO, w))
+	for i := 0; i < 100; i++ {
+		log.Info("message %d", i)
+	}
+	if err := log.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every record is either written or dropped
+	if contents, err := ioutil.ReadFile(testLogFile); err != nil {
+		t.Errorf("read(%q): %s", testLogFile, err)
+	} else if n := bytes.Count(contents, []byte("\n")); uint64(n)+w.Dropped() != 100 {
+		t.Errorf("Expected 100 lines and drops, found %d lines and %d drops", n, w.Dropped())
+	}
+	w.Close()
+}
+
+func TestRotateFileRotations(t *testing.T) {
+	const name = "_rotations.log"
+	rf := NewRotateFile(name)
+	rf.Maxsize = 10
+	defer removeFile(t, "_rotations.0.log")
+
+	rf.Write([]byte("rotate\n"))
+	rf.Flush()
+	if err := rf.Rotate(1); err != nil || rf.Rotations() != 0 {
+		t.Errorf("Should not rotate before maxsize, rotations %d, err %v", rf.Rotations(), err)
+	}
+	rf.Write([]byte("rotate again\n"))
+	rf.Flush()
+	if err := rf.Rotate(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rf.Rotations(), uint64(1); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	rf.Close()
+	os.Remove(name)
+}
+
+func TestFileAppenderFlushClose(t *testing.T) {
+	defer removeFile(t, testLogFile)
+
+	for i := 0; i < 20; i++ {
+		w, _ := NewAppender(testLogFile)
+		w.Enabled(&driver.Recorder{Level: l4g.INFO, Message: "start", Created: time.Now()})
+
+		done := make(chan struct{})
+		go func() {
+			w.Flush()
+			close(done)
+		}()
+		w.Close()
+
+		select {
+		case <-done:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("Flush should return when the appender is closed")
+		}
+		// Flush after closed writes the file directly
+		w.Flush()
+	}
+}
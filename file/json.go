@@ -30,3 +30,13 @@ func NewJSONAppender(filename string, args ...interface{}) (*JSONAppender, error
 func (*JSONAppender) Open(filename string, args ...interface{}) (driver.Appender, error) {
 	return NewJSONAppender(filename, args...)
 }
+
+// Validate checks the name-value option on a new appender, which does not
+// open the file. See driver.Validator.
+func (*JSONAppender) Validate(k string, v interface{}) error {
+	ja, err := NewJSONAppender("")
+	if err != nil {
+		return err
+	}
+	return ja.Set(k, v)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sync/atomic"
 	"time"
 
 	l4g "github.com/ccpaging/nxlog4go"
@@ -24,6 +25,8 @@ var (
 
 // RotateFile represents the buffered writer with lock, header, footer and rotating.
 type RotateFile struct {
+	rotations uint64 // files rotated; first for alignment
+
 	file *rolling.Writer // The opened file buffer writer
 
 	Header string // File header
@@ -50,6 +53,26 @@ func NewRotateFile(filename string) *RotateFile {
 	return rf
 }
 
+// Name returns the name of the file, or "" if it is closed.
+func (rf *RotateFile) Name() string {
+	if rf.file == nil {
+		return ""
+	}
+	return rf.file.Name
+}
+
+// Flush writes buffered data to the file.
+func (rf *RotateFile) Flush() {
+	if rf.file != nil {
+		rf.file.Flush()
+	}
+}
+
+// Rotations returns the number of files rotated.
+func (rf *RotateFile) Rotations() uint64 {
+	return atomic.LoadUint64(&rf.rotations)
+}
+
 // Close active RotateFile.
 func (rf *RotateFile) Close() {
 	if rf.file != nil {
@@ -80,7 +103,7 @@ func (rf *RotateFile) Write(bb []byte) (n int, err error) {
 	return
 }
 
-func backupFiles(name string, temp string, backup int) {
+func backupFiles(name string, temp string, backup int) error {
 	// May compress new log file here
 
 	l4g.LogLogTrace("Backup %s", temp)
@@ -113,23 +136,27 @@ func backupFiles(name string, temp string, backup int) {
 	for ; n > 0; n-- {
 		prev := path + fmt.Sprintf(".%d", n-1) + ext
 		l4g.LogLogTrace("Rename %s to %s", prev, slot)
-		os.Rename(prev, slot)
+		if err := os.Rename(prev, slot); err != nil {
+			l4g.LogLogError(err)
+		}
 		slot = prev
 	}
 
 	l4g.LogLogTrace("Rename %s to %s", temp, path+".0"+ext)
-	os.Rename(temp, path+".0"+ext)
+	return os.Rename(temp, path+".0"+ext)
 }
 
-// Rotate current log file if necessary
-func (rf *RotateFile) Rotate(backup int) {
+// Rotate current log file if necessary.
+//
+// Return the error of renaming the log file.
+func (rf *RotateFile) Rotate(backup int) error {
 	// l4g.LogLogTrace("Size %d, Maxsize %d", rf.file.Size(), rf.Maxsize)
 	if rf.Maxsize > 0 && rf.file.Size() >= rf.Maxsize {
 		l4g.LogLogTrace("Size > %d", rf.Maxsize)
 	} else if rf.Maxlines > 0 && rf.lines >= rf.Maxlines {
 		l4g.LogLogTrace("lines %d > %d", rf.lines, rf.Maxlines)
 	} else {
-		return
+		return nil
 	}
 
 	// Write footer
@@ -141,10 +168,11 @@ func (rf *RotateFile) Rotate(backup int) {
 
 	l4g.LogLogTrace("Close %s", name)
 	rf.file.Close()
+	atomic.AddUint64(&rf.rotations, 1)
 
 	if backup <= 0 {
 		os.Remove(name)
-		return
+		return nil
 	}
 
 	// File existed. File size > maxsize. Rotate
@@ -153,9 +181,12 @@ func (rf *RotateFile) Rotate(backup int) {
 	err := os.Rename(name, temp)
 	if err != nil {
 		l4g.LogLogError(err)
-		return
+		return err
 	}
 	rf.lines = 0
 
-	backupFiles(name, temp, backup)
+	if err = backupFiles(name, temp, backup); err != nil {
+		l4g.LogLogError(err)
+	}
+	return err
 }
@@ -3,9 +3,59 @@
 package nxlog4go
 
 import (
+	"sync/atomic"
+
 	"github.com/ccpaging/nxlog4go/driver"
 )
 
+// filterSnapshot holds an immutable map of filters. Dispatching reads the
+// map without locking, while changing filters copies the map, and stores
+// the new one under the lock of the logger. A nil map means inheriting the
+// filters of the parent.
+type filterSnapshot struct {
+	v atomic.Value // map[string]*driver.Filter
+}
+
+func newFilterSnapshot(m map[string]*driver.Filter) *filterSnapshot {
+	s := new(filterSnapshot)
+	s.store(m)
+	return s
+}
+
+func (s *filterSnapshot) load() map[string]*driver.Filter {
+	m, _ := s.v.Load().(map[string]*driver.Filter)
+	return m
+}
+
+func (s *filterSnapshot) store(m map[string]*driver.Filter) {
+	s.v.Store(m)
+	driver.InvalidateLevels()
+}
+
+func copyFilters(m map[string]*driver.Filter) map[string]*driver.Filter {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]*driver.Filter, len(m))
+	for name, f := range m {
+		c[name] = f
+	}
+	return c
+}
+
+// updateFilters calls fn with a copy of the filters of the logger, then
+// publishes it. A named logger inheriting filters gets its own filters.
+// The logger must be locked.
+func (l *Logger) updateFilters(fn func(m map[string]*driver.Filter)) {
+	m := copyFilters(l.filters.load())
+	if m == nil {
+		// A named logger overrides the inherited filters.
+		m = make(map[string]*driver.Filter)
+	}
+	fn(m)
+	l.filters.store(m)
+}
+
 // AddFilter adds the named appenders to the Logger which will only log messages at
 // or above level. This function should not be called from multiple goroutines.
 //
@@ -13,28 +63,36 @@ import (
 func (l *Logger) AddFilter(name string, level int, apps ...driver.Appender) *Logger {
 	f := &driver.Filter{
 		Name:    name,
-		Enabler: driver.AtAbove(level),
+		Enabler: driver.NewAtomicLevel(level),
 		Layout:  nil,
 		Apps:    apps,
 	}
 	return l.Attach(f)
 }
 
-// Attach adds the filters to logger.
+// Attach adds the filters to logger. Filters without error handler use the
+// error handler of the logger. See SetErrorHandler.
 func (l *Logger) Attach(filters ...*driver.Filter) *Logger {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	for _, f := range filters {
-		if f == nil {
-			continue
-		}
-		if _, ok := l.filters[f.Name]; ok {
-			// Existed
-			continue
+	h := l.errorHandler()
+
+	l.updateFilters(func(m map[string]*driver.Filter) {
+		for _, f := range filters {
+			if f == nil {
+				continue
+			}
+			if _, ok := m[f.Name]; ok {
+				// Existed
+				continue
+			}
+			if f.ErrorHandler == nil && h != nil {
+				f.SetErrorHandler(h)
+			}
+			m[f.Name] = f
 		}
-		l.filters[f.Name] = f
-	}
+	})
 
 	return l
 }
@@ -44,15 +102,21 @@ func (l *Logger) Detach(filters ...*driver.Filter) *Logger {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	for _, f := range filters {
-		if f == nil {
-			continue
-		}
-		if _, ok := l.filters[f.Name]; ok {
-			// Existed
-			delete(l.filters, f.Name)
-		}
+	if l.filters.load() == nil {
+		return l
 	}
 
+	l.updateFilters(func(m map[string]*driver.Filter) {
+		for _, f := range filters {
+			if f == nil {
+				continue
+			}
+			if _, ok := m[f.Name]; ok {
+				// Existed
+				delete(m, f.Name)
+			}
+		}
+	})
+
 	return l
 }
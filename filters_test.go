@@ -0,0 +1,91 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// queueApp counts recorders in Enabled, like asynchronous appenders which
+// queue recorders without encoding.
+type queueApp struct {
+	closeApp
+	n int64
+}
+
+func (a *queueApp) Enabled(r *driver.Recorder) bool {
+	atomic.AddInt64(&a.n, 1)
+	return false
+}
+
+func TestFiltersSnapshot(t *testing.T) {
+	l := NewLogger(INFO).SetOutput(nil)
+	app := &queueApp{}
+	l.AddFilter("app", INFO, app)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				l.Info("message")
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		f := &driver.Filter{Name: fmt.Sprintf("tmp%d", i), Enabler: driver.NewAtomicLevel(INFO), Apps: []driver.Appender{&queueApp{}}}
+		l.Attach(f)
+		l.Detach(f)
+	}
+	wg.Wait()
+
+	if got, want := atomic.LoadInt64(&app.n), int64(4000); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	if got, want := len(l.Filters()), 1; got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+}
+
+func BenchmarkDispatchParallelFilters(b *testing.B) {
+	l := NewLogger(INFO).SetOptions("caller", false).SetOutput(nil)
+	for i := 0; i < 4; i++ {
+		l.AddFilter(fmt.Sprintf("app%d", i), INFO, &queueApp{})
+	}
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info("message")
+		}
+	})
+}
+
+func BenchmarkDispatchParallelDisabledStdout(b *testing.B) {
+	l := NewLogger(ERROR).SetOptions("caller", false).SetOutput(ioutil.Discard)
+	l.AddFilter("app", INFO, &queueApp{})
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info("message")
+		}
+	})
+}
+
+func BenchmarkDispatchParallelStdout(b *testing.B) {
+	l := NewLogger(INFO).SetOptions("caller", false, "format", "%L %M").SetOutput(ioutil.Discard)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Info("message")
+		}
+	})
+}
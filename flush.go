@@ -0,0 +1,73 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// DefaultFlushTimeout is the default timeout of Sync.
+var DefaultFlushTimeout = 3 * time.Second
+
+// Flush writes all queued log recorders of the logger's filters, flushes
+// buffers of appenders and the output writer, and blocks until done.
+func (l *Logger) Flush() {
+	l.mu.Lock()
+	out := l.stdf.out
+	filters := make([]*driver.Filter, 0, len(l.outputs()))
+	for _, f := range l.outputs() {
+		if f != nil {
+			filters = append(filters, f)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, f := range filters {
+		f.Flush()
+	}
+
+	switch w := out.(type) {
+	case interface{ Flush() error }:
+		w.Flush()
+	case driver.Flusher:
+		w.Flush()
+	}
+}
+
+// Sync flushes the logger as Flush, but waits at most the flush timeout,
+// which is set by the "flushTimeout" option. Return error if timeout.
+//
+// Sync is called before exiting or panicking in Fatal and Panic, and after
+// logging in Critical.
+func (l *Logger) Sync() error {
+	l.mu.Lock()
+	timeout := l.flushTimeout
+	l.mu.Unlock()
+	if timeout <= 0 {
+		timeout = DefaultFlushTimeout
+	}
+
+	done := make(chan struct{})
+	go func() {
+		l.Flush()
+		close(done)
+	}()
+
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	select {
+	case <-done:
+		return nil
+	case <-t.C:
+		return fmt.Errorf("flush timeout after %v", timeout)
+	}
+}
+
+// Sync flushes the standard logger. See (*Logger).Sync.
+func Sync() error {
+	return std.Sync()
+}
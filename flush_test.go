@@ -0,0 +1,69 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+type blockingApp struct {
+	closeApp
+	release chan struct{}
+}
+
+func (a *blockingApp) Flush() { <-a.release }
+
+func TestSyncTimeout(t *testing.T) {
+	app := &blockingApp{closeApp{buf: new(bytes.Buffer)}, make(chan struct{})}
+	l := NewLogger(INFO).SetOutput(nil).SetOptions("flushTimeout", "10ms")
+	l.AddFilter("blocking", INFO, app)
+
+	if err := l.Sync(); err == nil {
+		t.Errorf("Sync should be timeout")
+	}
+
+	close(app.release)
+	if err := l.Sync(); err != nil {
+		t.Errorf("Sync should be done, found %v", err)
+	}
+}
+
+// pendingApp queues messages, and writes them when flushed.
+type pendingApp struct {
+	closeApp
+	mu      sync.Mutex
+	pending []string
+}
+
+func (a *pendingApp) Enabled(r *driver.Recorder) bool {
+	a.mu.Lock()
+	a.pending = append(a.pending, r.Message)
+	a.mu.Unlock()
+	return false
+}
+
+func (a *pendingApp) Flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, msg := range a.pending {
+		a.buf.WriteString(msg + "\n")
+	}
+	a.pending = nil
+}
+
+func TestCriticalSync(t *testing.T) {
+	app := &pendingApp{closeApp: closeApp{buf: new(bytes.Buffer)}}
+	l := NewLogger(INFO).SetOutput(nil)
+	l.AddFilter("pending", INFO, app)
+
+	l.Critical("logger")
+	NewEntry(l).Critical("entry")
+	if got, want := app.buf.String(), "logger\nentry\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
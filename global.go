@@ -52,9 +52,11 @@ func Error(arg0 interface{}, args ...interface{}) error {
 	return errors.New(msg)
 }
 
-// Critical is a wrapper for (*Logger).Critical
+// Critical is a wrapper for (*Logger).Critical, which flushes the standard
+// logger after logging.
 func Critical(arg0 interface{}, args ...interface{}) error {
 	msg := driver.ArgsToString(arg0, args...)
 	std.Log(2, CRITICAL, msg)
+	std.Sync()
 	return errors.New(msg)
 }
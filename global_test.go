@@ -17,7 +17,7 @@ func TestGloablLogger(t *testing.T) {
 	if global == nil {
 		t.Fatalf("GetLogger() should never return nil")
 	}
-	if global.stdf.level != WARN {
+	if global.stdf.level.Level() != WARN {
 		t.Fatalf("GetLogger() produced invalid logger (incorrect level)")
 	}
 
@@ -5,8 +5,11 @@ package nxlog4go
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ccpaging/nxlog4go/color"
+	"github.com/ccpaging/nxlog4go/driver"
 )
 
 // logging levels used by the logger
@@ -27,18 +30,81 @@ type levelString struct {
 	color color.Color
 }
 
-var levelMap map[int]*levelString
+var (
+	levelMu  sync.Mutex   // serializes RegisterLevel
+	levelMap atomic.Value // map[int]*levelString, copied on write
+	levelGen uint32       // increased when a level is registered
+)
 
 func init() {
-	levelMap = make(map[int]*levelString)
-	levelMap[FINEST] = &levelString{"FNST", "finest", color.Gray}
-	levelMap[FINE] = &levelString{"FINE", "fine", color.Green}
-	levelMap[DEBUG] = &levelString{"DEBG", "debug", color.Magenta}
-	levelMap[TRACE] = &levelString{"TRAC", "trace", color.Cyan}
-	levelMap[INFO] = &levelString{"INFO", "info", color.White}
-	levelMap[WARN] = &levelString{"WARN", "warn", color.LightYellow}
-	levelMap[ERROR] = &levelString{"EROR", "error", color.Red}
-	levelMap[CRITICAL] = &levelString{"CRIT", "critical", color.LightRed}
+	m := make(map[int]*levelString)
+	m[FINEST] = &levelString{"FNST", "finest", color.Gray}
+	m[FINE] = &levelString{"FINE", "fine", color.Green}
+	m[DEBUG] = &levelString{"DEBG", "debug", color.Magenta}
+	m[TRACE] = &levelString{"TRAC", "trace", color.Cyan}
+	m[INFO] = &levelString{"INFO", "info", color.White}
+	m[WARN] = &levelString{"WARN", "warn", color.LightYellow}
+	m[ERROR] = &levelString{"EROR", "error", color.Red}
+	m[CRITICAL] = &levelString{"CRIT", "critical", color.LightRed}
+	levelMap.Store(m)
+}
+
+func levels() map[int]*levelString {
+	return levelMap.Load().(map[int]*levelString)
+}
+
+// RegisterLevel registers a user-defined level with the short name, such as
+// "NOTC", the lower name, such as "notice", and the color. Then the level
+// can be logged with Log, encoded by layouts, and referenced by name in
+// configuration files. Registering an existed user-defined level replaces
+// its names.
+//
+// The built-in levels are consecutive integers, and levels are ordered by
+// number. Use a number above CRITICAL for a level which should pass the
+// CRITICAL filters, or a negative number for a level below FINEST. To order
+// a level between the built-in levels, use RegisterRankedLevel.
+func RegisterLevel(n int, short string, lower string, c color.Color) error {
+	if n >= FINEST && n <= CRITICAL {
+		return fmt.Errorf("level %d is built-in", n)
+	}
+	if short == "" || lower == "" {
+		return fmt.Errorf("level %d has empty name", n)
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	old := levels()
+	for i, ls := range old {
+		if i == n {
+			continue
+		}
+		if strings.EqualFold(ls.short, short) || strings.EqualFold(ls.lower, lower) ||
+			strings.EqualFold(ls.short, lower) || strings.EqualFold(ls.lower, short) {
+			return fmt.Errorf("level name %s or %s is used by level %d", short, lower, i)
+		}
+	}
+
+	m := make(map[int]*levelString, len(old)+1)
+	for i, ls := range old {
+		m[i] = ls
+	}
+	m[n] = &levelString{short, strings.ToLower(lower), c}
+	levelMap.Store(m)
+	atomic.AddUint32(&levelGen, 1)
+	return nil
+}
+
+// RegisterRankedLevel registers a user-defined level like RegisterLevel,
+// and orders it by the rank. The rank of a level is its number multiplied
+// by driver.LevelStep, so the built-in INFO ranks 40 and WARN ranks 50, and
+// a level of the rank 45 is ordered between them. See driver.LevelRank.
+func RegisterRankedLevel(n int, rank int, short string, lower string, c color.Color) error {
+	if err := RegisterLevel(n, short, lower, c); err != nil {
+		return err
+	}
+	driver.RankLevel(n, rank)
+	return nil
 }
 
 // Level is the integer logging levels
@@ -46,7 +112,7 @@ type Level int
 
 // String return the string of integer Level
 func (l Level) String() string {
-	ls, ok := levelMap[int(l)]
+	ls, ok := levels()[int(l)]
 	if ok {
 		return ls.short
 	}
@@ -54,16 +120,38 @@ func (l Level) String() string {
 }
 
 func (l Level) string2int(s string) int {
+	if n, ok := levelOf(s); ok {
+		return n
+	}
+	return int(l)
+}
+
+// levelOf returns the level of the short or lower name, ignoring case.
+func levelOf(s string) (int, bool) {
 	s = strings.ToLower(s)
-	for i, ls := range levelMap {
+	for i, ls := range levels() {
 		if s == strings.ToLower(ls.short) || s == ls.lower {
-			return i
+			return i, true
 		}
 	}
 	if s == "warning" {
-		return WARN
+		return WARN, true
 	}
-	return int(l)
+	return 0, false
+}
+
+// levelName returns the upper name of the level, like "DEBUG".
+func levelName(n int) string {
+	if ls, ok := levels()[n]; ok {
+		return strings.ToUpper(ls.lower)
+	}
+	return Level(n).String()
+}
+
+// Name returns the upper name of the level, like "DEBUG", which can be
+// casted back by IntE.
+func (l Level) Name() string {
+	return levelName(int(l))
 }
 
 // IntE casts an interface to a level int.
@@ -87,20 +175,20 @@ func (l Level) Int(v interface{}) int {
 	return n
 }
 
+// Color returns the color of the level, or red if the level is unknown.
+func (l Level) Color() color.Color {
+	if ls, ok := levels()[int(l)]; ok {
+		return ls.color
+	}
+	return color.Red
+}
+
 // ColorBytes return the ANSI color bytes by level
 func (l Level) colorBytes(n int) []byte {
-	ls, ok := levelMap[int(n)]
-	if ok {
-		return ls.color.Bytes()
-	}
-	return color.Red.Bytes()
+	return Level(n).Color().Bytes()
 }
 
 // Colorize return the ANSI color wrap bytes by level
 func (l Level) colorize(s string) []byte {
-	ls, ok := levelMap[int(l)]
-	if ok {
-		return ls.color.Wrap([]byte(s))
-	}
-	return color.Red.Wrap([]byte(s))
+	return l.Color().Wrap([]byte(s))
 }
@@ -0,0 +1,97 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"runtime"
+	"sync/atomic"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// minLevel returns the minimum level which may be written to the output
+// writer or passed to filters. It is cached with the generation of levels,
+// and computed again after levels, filters or appenders are changed.
+// See driver.LevelGeneration.
+func (l *Logger) minLevel() int {
+	c := atomic.LoadUint64(&l.levelCache)
+	g := driver.LevelGeneration()
+	if uint32(c>>32) == g {
+		return int(int32(uint32(c)))
+	}
+
+	n := l.computeMinLevel()
+	atomic.StoreUint64(&l.levelCache, uint64(g)<<32|uint64(uint32(int32(n))))
+	return n
+}
+
+// computeMinLevel computes the minimum level from the level of the logger,
+// the output writer and the filters, which may be inherited.
+func (l *Logger) computeMinLevel() int {
+	t := l.threshold()
+
+	n := driver.MinLevelNone
+	if st := l.stdf.state.Load().(stdState); st.on {
+		if t != nil {
+			n = t.Level()
+		} else {
+			n = st.level.Level()
+		}
+	}
+
+	for _, f := range l.outputs() {
+		if f == nil {
+			continue
+		}
+		if m := f.MinLevel(); driver.LevelBelow(m, n) {
+			n = m
+		}
+	}
+
+	if t != nil && driver.LevelBelow(n, t.Level()) {
+		n = t.Level()
+	}
+	return n
+}
+
+// needs returns the attributes of log recorders used by the output writer,
+// the processors and the filters. It is cached like minLevel.
+func (l *Logger) needs() driver.Attrs {
+	c := atomic.LoadUint64(&l.needsCache)
+	g := driver.LevelGeneration()
+	if uint32(c>>32) == g {
+		return driver.Attrs(uint32(c))
+	}
+
+	n := l.computeNeeds()
+	atomic.StoreUint64(&l.needsCache, uint64(g)<<32|uint64(uint32(n)))
+	return n
+}
+
+func (l *Logger) computeNeeds() (n driver.Attrs) {
+	if l.stdf.active() {
+		l.mu.Lock()
+		n |= driver.NeedsOf(l.stdf.lo)
+		l.mu.Unlock()
+	}
+	for p := l; p != nil; p = p.parent {
+		n |= p.ownProcessors().Needs()
+	}
+	for _, f := range l.outputs() {
+		if f != nil {
+			n |= f.Needs()
+		}
+	}
+	return
+}
+
+// callerPC returns the program counter of the caller, as runtime.Caller
+// of the function calling callerPC does. The source and the line are
+// resolved when needed. See driver.Recorder.Caller.
+func callerPC(calldepth int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(calldepth+2, pcs[:]) < 1 {
+		return 0
+	}
+	return pcs[0]
+}
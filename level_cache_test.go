@@ -0,0 +1,120 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// levelApp is a queueApp with a level, like the built-in appenders.
+type levelApp struct {
+	queueApp
+	level driver.AtomicLevel
+}
+
+func (a *levelApp) Level() int     { return a.level.Level() }
+func (a *levelApp) SetLevel(n int) { a.level.SetLevel(n) }
+
+func TestLevelCache(t *testing.T) {
+	l := NewLogger(INFO).SetOutput(nil)
+	if l.enabled(CRITICAL) {
+		t.Errorf("Levels should be disabled without output and filters")
+	}
+
+	app := &levelApp{}
+	l.AddFilter("app", WARN, app)
+	if l.enabled(INFO) || !l.enabled(WARN) {
+		t.Errorf("Levels should be enabled at or above the filter's level only")
+	}
+	l.Info("hidden")
+	l.Warn("shown")
+	if app.n != 1 {
+		t.Errorf("Disabled levels should not be dispatched, got %d recorders", app.n)
+	}
+
+	l.Filters()["app"].Enabler.(*driver.AtomicLevel).SetLevel(DEBUG)
+	if !l.enabled(DEBUG) {
+		t.Errorf("Changing the filter's level should be seen")
+	}
+	app.SetLevel(ERROR)
+	if l.enabled(WARN) || !l.enabled(ERROR) {
+		t.Errorf("The appender's level should raise the filter's level")
+	}
+
+	l.Attach(&driver.Filter{Name: "sampled", Enabler: driver.NewSampler(nil, time.Second, 1, 1), Apps: []driver.Appender{&queueApp{}}})
+	if !l.enabled(FINEST) {
+		t.Errorf("Filters without level should enable all levels")
+	}
+	l.Detach(l.Filters()["sampled"])
+
+	pool := l.Named("db.pool")
+	pool.SetLevel(CRITICAL)
+	if pool.enabled(ERROR) || !l.enabled(ERROR) {
+		t.Errorf("The level of a named logger should apply to itself only")
+	}
+	pool.ResetLevel()
+	if !pool.enabled(ERROR) {
+		t.Errorf("Resetting level should inherit the filters of the parent")
+	}
+
+	l.SetOutput(new(bytes.Buffer))
+	if !l.enabled(INFO) || l.enabled(DEBUG) {
+		t.Errorf("The output writer should enable its level")
+	}
+	l.Enable(false)
+	l.Close()
+	if l.enabled(CRITICAL) {
+		t.Errorf("Levels should be disabled after closing")
+	}
+}
+
+// callerApp records the caller of the last recorder, and declares the
+// attributes it needs.
+type callerApp struct {
+	closeApp
+	needs  driver.Attrs
+	pc     uintptr
+	source string
+}
+
+func (a *callerApp) Needs() driver.Attrs { return a.needs }
+func (a *callerApp) Enabled(r *driver.Recorder) bool {
+	a.pc = r.PC
+	a.source, _ = r.Caller()
+	return false
+}
+
+func TestLazyCaller(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%M").SetOutput(buf)
+	app := &callerApp{}
+	l.AddFilter("app", INFO, app)
+
+	l.Info("message")
+	if app.pc != 0 {
+		t.Errorf("Caller should not be recorded if nobody needs it")
+	}
+
+	app.needs = driver.AttrCaller
+	l.Filters()["app"].Update(func(*driver.Filter) {})
+	l.Info("message")
+	if app.pc == 0 || !strings.HasSuffix(app.source, "level_cache_test.go") {
+		t.Errorf("Caller should be recorded if an appender needs it, got %q", app.source)
+	}
+
+	app.needs = 0
+	l.Filters()["app"].Update(func(*driver.Filter) {})
+	l.Set("format", "%S %M")
+	l.Info("message")
+	if app.pc == 0 {
+		t.Errorf("Caller should be recorded if the layout needs it")
+	}
+	if got := buf.String(); !strings.HasSuffix(got, "level_cache_test.go message\n") {
+		t.Errorf("   got %q", got)
+	}
+}
@@ -5,6 +5,7 @@ package nxlog4go
 import (
 	"bytes"
 	"strings"
+	"sync/atomic"
 
 	"github.com/ccpaging/nxlog4go/color"
 	"github.com/ccpaging/nxlog4go/driver"
@@ -19,14 +20,23 @@ func init() {
 	patt.DefaultEncoders.EndColorizer = NewEndColorizer("")
 }
 
+// cacheLevel encodes level names. The names are cached for all registered
+// levels, and replaced atomically when levels are registered, so the
+// encoder is safe for concurrent use.
 type cacheLevel struct {
-	cache map[int][]byte
+	cache atomic.Value // *levelCache
 
 	color bool
 	upper bool
 	short bool
 }
 
+// levelCache is an immutable cache of encoded level names.
+type levelCache struct {
+	gen   uint32 // the levelGen of the cache
+	names map[int][]byte
+}
+
 // NewLevelEncoder creates a new level encoder.
 func NewLevelEncoder(typ string) patt.Encoder {
 	e := &cacheLevel{}
@@ -49,37 +59,48 @@ func (*cacheLevel) NewEncoder(typ string) patt.Encoder {
 	default:
 		e.short = true
 	}
+	e.load()
 	return e
 }
 
-func (e *cacheLevel) Encode(out *bytes.Buffer, r *driver.Recorder) {
-	n := r.Level
-	if e.cache == nil {
-		e.cache = make(map[int][]byte, len(levelMap))
-		for i, ls := range levelMap {
-			s := ls.lower
-			if e.short {
-				s = ls.short
-			} else if e.upper {
-				s = strings.ToUpper(s)
-			}
-
-			if e.color {
-				e.cache[i] = Level(i).colorize(s)
-			} else {
-				e.cache[i] = []byte(s)
-			}
+// load returns the cache, which is built again after levels are
+// registered.
+func (e *cacheLevel) load() *levelCache {
+	gen := atomic.LoadUint32(&levelGen)
+	if c, _ := e.cache.Load().(*levelCache); c != nil && c.gen == gen {
+		return c
+	}
+
+	m := levels()
+	c := &levelCache{gen: gen, names: make(map[int][]byte, len(m))}
+	for i, ls := range m {
+		s := ls.lower
+		if e.short {
+			s = ls.short
+		} else if e.upper {
+			s = strings.ToUpper(s)
+		}
+
+		if e.color {
+			c.names[i] = Level(i).colorize(s)
+		} else {
+			c.names[i] = []byte(s)
 		}
 	}
+	e.cache.Store(c)
+	return c
+}
 
-	if b, ok := e.cache[n]; ok {
+func (e *cacheLevel) Encode(out *bytes.Buffer, r *driver.Recorder) {
+	n := r.Level
+	if b, ok := e.load().names[n]; ok {
 		out.Write(b)
 	} else {
 		s := Level(n).String()
 		if e.color {
 			out.Write(Level(n).colorize(s))
 		} else {
-			out.Write([]byte(s))
+			out.WriteString(s)
 		}
 	}
 }
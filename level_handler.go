@@ -0,0 +1,169 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// LevelHandler is an http.Handler which reports and changes the levels
+// of a logger, its filters and their appenders at runtime.
+//
+//	GET  /                        - Report all levels as JSON
+//	PUT  /?level=DEBUG            - Change the level of the logger
+//	PUT  /?filter=file&level=...  - Change the level of the named filter
+//	PUT  /?filter=file&appender=0&level=...
+//	                              - Change the level of the filter's appender
+//
+// The level of PUT may be sent as JSON body also, such as {"level":"DEBUG"}.
+type LevelHandler struct {
+	log *Logger
+}
+
+// NewLevelHandler creates a level handler for the logger.
+func NewLevelHandler(l *Logger) *LevelHandler {
+	return &LevelHandler{log: l}
+}
+
+type levelAppender struct {
+	Index int    `json:"index"`
+	Type  string `json:"type,omitempty"`
+	Level string `json:"level,omitempty"`
+}
+
+type levelFilter struct {
+	Name      string          `json:"name"`
+	Level     string          `json:"level,omitempty"`
+	Appenders []levelAppender `json:"appenders,omitempty"`
+}
+
+type levelState struct {
+	Level   string        `json:"level"`
+	Filters []levelFilter `json:"filters,omitempty"`
+}
+
+func (h *LevelHandler) state() *levelState {
+	l := h.log
+	st := &levelState{Level: levelName(l.Level())}
+
+	l.mu.Lock()
+	outputs := l.outputs()
+	l.mu.Unlock()
+
+	for name, f := range outputs {
+		if f == nil {
+			continue
+		}
+		lf := levelFilter{Name: name}
+		f.View(func(f *driver.Filter) {
+			if lv := driver.FindLeveler(f.Enabler); lv != nil {
+				lf.Level = levelName(lv.Level())
+			}
+			for i, a := range f.Apps {
+				la := levelAppender{Index: i, Type: driver.NameOf(a)}
+				if lv := driver.FindLeveler(a); lv != nil {
+					la.Level = levelName(lv.Level())
+				}
+				lf.Appenders = append(lf.Appenders, la)
+			}
+		})
+		st.Filters = append(st.Filters, lf)
+	}
+	sort.Slice(st.Filters, func(i, j int) bool {
+		return st.Filters[i].Name < st.Filters[j].Name
+	})
+	return st
+}
+
+// leveler returns the Leveler addressed by the request.
+func (h *LevelHandler) leveler(r *http.Request) (driver.Leveler, int, error) {
+	l := h.log
+	q := r.URL.Query()
+	name := q.Get("filter")
+	if name == "" {
+		if q.Get("appender") != "" {
+			return nil, http.StatusBadRequest, fmt.Errorf("appender without filter")
+		}
+		return l, 0, nil
+	}
+
+	l.mu.Lock()
+	f, ok := l.outputs()[name]
+	l.mu.Unlock()
+	if !ok || f == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("unknown filter %q", name)
+	}
+
+	var v interface{}
+	s := q.Get("appender")
+	f.View(func(f *driver.Filter) {
+		if s == "" {
+			v = f.Enabler
+		} else if i, err := strconv.Atoi(s); err == nil && i >= 0 && i < len(f.Apps) {
+			v = f.Apps[i]
+		}
+	})
+	if s != "" && v == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("unknown appender %q of filter %q", s, name)
+	}
+	if lv := driver.FindLeveler(v); lv != nil {
+		return lv, 0, nil
+	}
+	return nil, http.StatusNotFound, fmt.Errorf("no level of filter %q", name)
+}
+
+// requestLevel reads the level from the query or the JSON body.
+func requestLevel(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("level")
+	if s == "" && r.Body != nil {
+		var body struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+			return 0, err
+		}
+		s = body.Level
+	}
+	if s == "" {
+		return 0, fmt.Errorf("missing level")
+	}
+	if n, ok := levelOf(s); ok {
+		return n, nil
+	}
+	return 0, fmt.Errorf("unknown level %q", s)
+}
+
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		n, err := requestLevel(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		lv, code, err := h.leveler(r)
+		if err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+		lv.SetLevel(n)
+		// Levelers other than driver.AtomicLevel do not invalidate the
+		// cached levels of loggers.
+		driver.InvalidateLevels()
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.state())
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+func TestLevelHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%L %M").SetOutput(buf)
+	l.AddFilter("test", WARN, nil)
+	h := NewLevelHandler(l)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if got, want := rr.Body.String(), `{"level":"INFO","filters":[{"name":"test","level":"WARN","appenders":[{"index":0}]}]}`+"\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("PUT", "/?level=debug", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Status %d, %s", rr.Code, rr.Body.String())
+	}
+	l.Debug("raised")
+	if got, want := buf.String(), "DEBG raised\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("PUT", "/?filter=test", strings.NewReader(`{"level":"ERROR"}`)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Status %d, %s", rr.Code, rr.Body.String())
+	}
+	if got, want := l.Filters()["test"].Enabler.(*driver.AtomicLevel).Level(), ERROR; got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+
+	for _, tc := range []struct {
+		method, target string
+		code           int
+	}{
+		{"PUT", "/?level=verbose", http.StatusBadRequest},
+		{"PUT", "/?filter=none&level=INFO", http.StatusNotFound},
+		{"PUT", "/?filter=test&appender=0&level=INFO", http.StatusNotFound},
+		{"PUT", "/?filter=test&appender=1&level=INFO", http.StatusNotFound},
+		{"POST", "/", http.StatusMethodNotAllowed},
+	} {
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.target, nil))
+		if rr.Code != tc.code {
+			t.Errorf("%s %s: got %d, want %d", tc.method, tc.target, rr.Code, tc.code)
+		}
+	}
+}
+
+func TestLevelHandlerUpdate(t *testing.T) {
+	l := NewLogger(INFO).SetOutput(nil)
+	l.AddFilter("test", WARN, nil)
+	f := l.Filters()["test"]
+	h := NewLevelHandler(l)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			f.Update(func(f *driver.Filter) {
+				f.Enabler = driver.NewAtomicLevel(WARN)
+				f.Apps = []driver.Appender{nil}
+			})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/?filter=test&level=ERROR", nil))
+	}
+	<-done
+}
@@ -4,9 +4,14 @@ package nxlog4go
 
 import (
 	"bytes"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/ccpaging/nxlog4go/color"
 	"github.com/ccpaging/nxlog4go/driver"
+	"github.com/ccpaging/nxlog4go/patt"
 )
 
 func TestAtAboveLevelEnabler(t *testing.T) {
@@ -102,3 +107,138 @@ func TestColorLevelEnd(t *testing.T) {
 		out.Reset()
 	}
 }
+
+func TestRegisterLevel(t *testing.T) {
+	const AUDIT = CRITICAL + 10
+
+	if err := RegisterLevel(INFO, "NOTC", "notice", color.Blue); err == nil {
+		t.Errorf("Built-in level should not be replaced")
+	}
+	if err := RegisterLevel(AUDIT, "WARN", "audit", color.Blue); err == nil {
+		t.Errorf("Used level name should not be registered")
+	}
+	if err := RegisterLevel(AUDIT, "AUDT", "audit", color.Blue); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := Level(AUDIT).String(), "AUDT"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+	if got, want := Level(INFO).Int("Audit"), AUDIT; got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%L %M", "levelEncoder", "upper").SetOutput(buf)
+	l.Info("before")
+	l.Log(1, AUDIT, "login")
+	if got, want := buf.String(), "INFO before\nAUDIT login\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestRegisterRankedLevel(t *testing.T) {
+	const (
+		NOTE     = CRITICAL + 20
+		SECURITY = CRITICAL + 21
+	)
+
+	if err := RegisterRankedLevel(INFO, 45, "NOTE", "note", color.Blue); err == nil {
+		t.Errorf("Built-in level should not be ranked")
+	}
+	if err := RegisterRankedLevel(NOTE, INFO*driver.LevelStep+5, "NOTE", "note", color.Blue); err != nil {
+		t.Fatal(err)
+	}
+	defer driver.RankLevel(NOTE, NOTE*driver.LevelStep)
+	if err := RegisterRankedLevel(SECURITY, ERROR*driver.LevelStep+5, "SECU", "security", color.LightRed); err != nil {
+		t.Fatal(err)
+	}
+	defer driver.RankLevel(SECURITY, SECURITY*driver.LevelStep)
+
+	// Ordered between the built-in levels
+	if !(driver.LevelBelow(INFO, NOTE) && driver.LevelBelow(NOTE, WARN) &&
+		driver.LevelBelow(ERROR, SECURITY) && driver.LevelBelow(SECURITY, CRITICAL)) {
+		t.Errorf("Levels should be ordered by rank")
+	}
+	if got, want := Level(DEBUG).Int("note"), NOTE; got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+
+	// Filter thresholds
+	buf, app := new(bytes.Buffer), &closeApp{buf: new(bytes.Buffer)}
+	l := NewLogger(NOTE).SetOptions("format", "%L %M").SetOutput(buf)
+	l.Attach(&driver.Filter{Name: "audit", Enabler: driver.NewAtomicLevel(SECURITY),
+		Layout: patt.NewLayout("%L %M", "levelEncoder", "lower"), Apps: []driver.Appender{app}})
+	l.Info("info")
+	l.Log(1, NOTE, "note")
+	l.Warn("warn")
+	l.Error("error")
+	l.Log(1, SECURITY, "security")
+	l.Critical("critical")
+	if got, want := buf.String(), "NOTE note\nWARN warn\nEROR error\nSECU security\nCRIT critical\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+	if got, want := app.buf.String(), "security security\ncritical critical\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	// Level encoders
+	e0 := NewLevelEncoder("")
+	out := new(bytes.Buffer)
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{"", "NOTE"},
+		{"upper", "NOTE"},
+		{"lower", "note"},
+		{"upperColor", string(color.Blue.Wrap([]byte("NOTE")))},
+	} {
+		e0.NewEncoder(tt.name).Encode(out, &driver.Recorder{Level: NOTE})
+		if got := out.String(); got != tt.want {
+			t.Errorf("Incorrect level format of [%s]: %q should be %q", tt.name, got, tt.want)
+		}
+		out.Reset()
+	}
+}
+
+func TestSharedLayoutConcurrent(t *testing.T) {
+	const NOTICE = CRITICAL + 20
+
+	lo := patt.NewLayout("%L %M", "levelEncoder", "upper")
+	out, app := new(bytes.Buffer), &closeApp{buf: new(bytes.Buffer)}
+	l := NewLogger(INFO).SetLayout(lo).SetOutput(out)
+	l.Attach(&driver.Filter{Name: "app", Enabler: driver.NewAtomicLevel(INFO), Layout: lo, Apps: []driver.Appender{app}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Info("message")
+				runtime.Gosched()
+			}
+		}()
+	}
+	// Registering a level replaces the caches of level encoders
+	for j := 0; j < 100; j++ {
+		RegisterLevel(NOTICE, "NOTC", "notice", color.Blue)
+		runtime.Gosched()
+	}
+	wg.Wait()
+
+	want := strings.Repeat("INFO message\n", 400)
+	if got := out.String(); got != want {
+		t.Errorf("Output writer got %q", got)
+	}
+	if got := app.buf.String(); got != want {
+		t.Errorf("Filter got %q", got)
+	}
+}
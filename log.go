@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"runtime"
 	"time"
 
 	"github.com/ccpaging/nxlog4go/driver"
@@ -45,6 +44,7 @@ func (l *Logger) SetOutput(w io.Writer) *Logger {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.stdf.out = w
+	l.stdf.publish()
 	return l
 }
 
@@ -65,19 +65,18 @@ func (l *Logger) Output(calldepth int, s string) error {
 		s = s[0 : len(s)-1]
 	}
 
-	r := &driver.Recorder{
-		Prefix:  l.prefix,
-		Level:   l.stdf.level,
-		Message: s,
-		Created: time.Now(),
-	}
+	r := driver.NewRecorder()
+	r.Prefix = l.prefix
+	r.Level = l.Level()
+	r.Message = s
+	r.Created = time.Now()
 
-	if l.caller {
-		// Determine caller func - it's expensive.
-		_, r.Source, r.Line, _ = runtime.Caller(calldepth)
+	if l.caller && l.needs()&driver.AttrCaller != 0 {
+		r.PC = callerPC(calldepth)
 	}
 
 	l.Dispatch(r)
+	r.Release()
 
 	return nil
 }
@@ -96,42 +95,48 @@ func (l *Logger) Print(v ...interface{}) { l.Output(2, fmt.Sprint(v...)) }
 // Arguments are handled in the manner of fmt.Println.
 func (l *Logger) Println(v ...interface{}) { l.Output(2, fmt.Sprintln(v...)) }
 
-// Fatal is equivalent to l.Print() followed by a call to os.Exit(1).
+// Fatal is equivalent to l.Print() followed by Sync() and a call to os.Exit(1).
 func (l *Logger) Fatal(v ...interface{}) {
 	l.Output(2, fmt.Sprint(v...))
+	l.Sync()
 	os.Exit(1)
 }
 
-// Fatalf is equivalent to l.Printf() followed by a call to os.Exit(1).
+// Fatalf is equivalent to l.Printf() followed by Sync() and a call to os.Exit(1).
 func (l *Logger) Fatalf(format string, v ...interface{}) {
 	l.Output(2, fmt.Sprintf(format, v...))
+	l.Sync()
 	os.Exit(1)
 }
 
-// Fatalln is equivalent to l.Println() followed by a call to os.Exit(1).
+// Fatalln is equivalent to l.Println() followed by Sync() and a call to os.Exit(1).
 func (l *Logger) Fatalln(v ...interface{}) {
 	l.Output(2, fmt.Sprintln(v...))
+	l.Sync()
 	os.Exit(1)
 }
 
-// Panic is equivalent to l.Print() followed by a call to panic().
+// Panic is equivalent to l.Print() followed by Sync() and a call to panic().
 func (l *Logger) Panic(v ...interface{}) {
 	s := fmt.Sprint(v...)
 	l.Output(2, s)
+	l.Sync()
 	panic(s)
 }
 
-// Panicf is equivalent to l.Printf() followed by a call to panic().
+// Panicf is equivalent to l.Printf() followed by Sync() and a call to panic().
 func (l *Logger) Panicf(format string, v ...interface{}) {
 	s := fmt.Sprintf(format, v...)
 	l.Output(2, s)
+	l.Sync()
 	panic(s)
 }
 
-// Panicln is equivalent to l.Println() followed by a call to panic().
+// Panicln is equivalent to l.Println() followed by Sync() and a call to panic().
 func (l *Logger) Panicln(v ...interface{}) {
 	s := fmt.Sprintln(v...)
 	l.Output(2, s)
+	l.Sync()
 	panic(s)
 }
 
@@ -224,42 +229,48 @@ func Println(v ...interface{}) {
 	std.Output(2, fmt.Sprintln(v...))
 }
 
-// Fatal is equivalent to Print() followed by a call to os.Exit(1).
+// Fatal is equivalent to Print() followed by Sync() and a call to os.Exit(1).
 func Fatal(v ...interface{}) {
 	std.Output(2, fmt.Sprint(v...))
+	std.Sync()
 	os.Exit(1)
 }
 
-// Fatalf is equivalent to Printf() followed by a call to os.Exit(1).
+// Fatalf is equivalent to Printf() followed by Sync() and a call to os.Exit(1).
 func Fatalf(format string, v ...interface{}) {
 	std.Output(2, fmt.Sprintf(format, v...))
+	std.Sync()
 	os.Exit(1)
 }
 
-// Fatalln is equivalent to Println() followed by a call to os.Exit(1).
+// Fatalln is equivalent to Println() followed by Sync() and a call to os.Exit(1).
 func Fatalln(v ...interface{}) {
 	std.Output(2, fmt.Sprintln(v...))
+	std.Sync()
 	os.Exit(1)
 }
 
-// Panic is equivalent to Print() followed by a call to panic().
+// Panic is equivalent to Print() followed by Sync() and a call to panic().
 func Panic(v ...interface{}) {
 	s := fmt.Sprint(v...)
 	std.Output(2, s)
+	std.Sync()
 	panic(s)
 }
 
-// Panicf is equivalent to Printf() followed by a call to panic().
+// Panicf is equivalent to Printf() followed by Sync() and a call to panic().
 func Panicf(format string, v ...interface{}) {
 	s := fmt.Sprintf(format, v...)
 	std.Output(2, s)
+	std.Sync()
 	panic(s)
 }
 
-// Panicln is equivalent to Println() followed by a call to panic().
+// Panicln is equivalent to Println() followed by Sync() and a call to panic().
 func Panicln(v ...interface{}) {
 	s := fmt.Sprintln(v...)
 	std.Output(2, s)
+	std.Sync()
 	panic(s)
 }
 
@@ -4,7 +4,6 @@ package nxlog4go
 
 import (
 	"errors"
-	"runtime"
 	"time"
 
 	"github.com/ccpaging/nxlog4go/driver"
@@ -20,21 +19,18 @@ func (l *Logger) Log(calldepth int, level int, arg0 interface{}, args ...interfa
 		return
 	}
 
-	r := &driver.Recorder{
-		Prefix:  l.prefix,
-		Level:   level,
-		Message: driver.ArgsToString(arg0, args...),
-		Created: time.Now(),
-	}
+	r := driver.NewRecorder()
+	r.Prefix = l.prefix
+	r.Level = level
+	r.Message = driver.ArgsToString(arg0, args...)
+	r.Created = time.Now()
 
-	if l.caller {
-		// Determine caller func - it's expensive.
-		_, r.Source, r.Line, _ = runtime.Caller(calldepth)
-	} else {
-		r.Source, r.Line = "", 0
+	if l.caller && l.needs()&driver.AttrCaller != 0 {
+		r.PC = callerPC(calldepth)
 	}
 
 	l.Dispatch(r)
+	r.Release()
 }
 
 // Finest logs a message at the finest log level.
@@ -89,9 +85,11 @@ func (l *Logger) Error(arg0 interface{}, args ...interface{}) error {
 
 // Critical logs a message at the critical log level and returns the formatted error,
 // See Warn for an explanation of the performance and ArgsToString for an explanation
-// of the parameters.
+// of the parameters. The logger is flushed by Sync after logging, which
+// waits at most the "flushTimeout".
 func (l *Logger) Critical(arg0 interface{}, args ...interface{}) error {
 	msg := driver.ArgsToString(arg0, args...)
 	l.Log(2, CRITICAL, msg)
+	l.Sync()
 	return errors.New(msg)
 }
@@ -0,0 +1,179 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// levelCounts counts the log recorders dispatched by a logger, by level.
+type levelCounts struct {
+	builtin [CRITICAL + 1]uint64 // the number of log recorders of the built-in levels
+	others  sync.Map             // map[int]*uint64, the number of log recorders of user-defined levels
+	parent  *levelCounts         // the counts of the parent or the cloned logger. May be nil
+}
+
+// add counts a log recorder at the level, and in the parent counts.
+func (c *levelCounts) add(level int) {
+	for ; c != nil; c = c.parent {
+		if level >= FINEST && level <= CRITICAL {
+			atomic.AddUint64(&c.builtin[level], 1)
+			continue
+		}
+		n, ok := c.others.Load(level)
+		if !ok {
+			n, _ = c.others.LoadOrStore(level, new(uint64))
+		}
+		atomic.AddUint64(n.(*uint64), 1)
+	}
+}
+
+// load returns the counts by the upper name of the level.
+func (c *levelCounts) load() map[string]uint64 {
+	m := make(map[string]uint64)
+	for i := range c.builtin {
+		m[levelName(i)] = atomic.LoadUint64(&c.builtin[i])
+	}
+	c.others.Range(func(k, v interface{}) bool {
+		m[levelName(k.(int))] = atomic.LoadUint64(v.(*uint64))
+		return true
+	})
+	return m
+}
+
+// Metrics is the metrics of a logger.
+type Metrics struct {
+	Levels  map[string]uint64      `json:"levels"`            // the number of log recorders dispatched by the logger, by level
+	Filters []driver.FilterMetrics `json:"filters,omitempty"` // sorted by name
+}
+
+// Metrics returns the current metrics of the logger and its filters, which
+// may be inherited. The counts of levels include the log recorders of the
+// clones and the named children of the logger.
+func (l *Logger) Metrics() *Metrics {
+	m := &Metrics{Levels: l.counts.load()}
+	for _, f := range l.outputs() {
+		if f != nil {
+			m.Filters = append(m.Filters, f.Metrics())
+		}
+	}
+	sort.Slice(m.Filters, func(i, j int) bool {
+		return m.Filters[i].Name < m.Filters[j].Name
+	})
+	return m
+}
+
+// PublishMetrics publishes the metrics of the logger to expvar with the
+// name, such as "nxlog4go". Like expvar.Publish, it panics if the name is
+// used already.
+func PublishMetrics(name string, l *Logger) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return l.Metrics()
+	}))
+}
+
+// MetricsHandler is an http.Handler which writes the metrics of a logger in
+// the Prometheus text exposition format.
+//
+//	nxlog4go_records_total{level="INFO"}
+//	nxlog4go_filter_records_total{filter="file"}
+//	nxlog4go_appender_bytes_total{appender="file",type="file"}
+//	nxlog4go_appender_errors_total{appender="file",type="file"}
+//	nxlog4go_appender_rotations_total{appender="file",type="file"}
+//	nxlog4go_appender_dropped_total{appender="file",type="file"}
+//	nxlog4go_appender_queue_length{appender="file",type="file"}
+type MetricsHandler struct {
+	log *Logger
+}
+
+// NewMetricsHandler creates a metrics handler for the logger.
+func NewMetricsHandler(l *Logger) *MetricsHandler {
+	return &MetricsHandler{log: l}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type promWriter struct {
+	w *bufio.Writer
+}
+
+func (p promWriter) header(name, typ, help string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func (p promWriter) sample(name string, v interface{}, labels ...string) {
+	p.w.WriteString(name)
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i == 0 {
+			p.w.WriteByte('{')
+		} else {
+			p.w.WriteByte(',')
+		}
+		fmt.Fprintf(p.w, `%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1]))
+	}
+	if len(labels) > 1 {
+		p.w.WriteByte('}')
+	}
+	fmt.Fprintf(p.w, " %v\n", v)
+}
+
+func (p promWriter) appenders(m *Metrics, name, typ, help string, value func(a *driver.AppenderMetrics) (interface{}, bool)) {
+	p.header(name, typ, help)
+	for _, f := range m.Filters {
+		for i := range f.Appenders {
+			a := &f.Appenders[i]
+			if v, ok := value(a); ok {
+				p.sample(name, v, "appender", a.Name, "type", a.Type)
+			}
+		}
+	}
+}
+
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	m := h.log.Metrics()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p := promWriter{bufio.NewWriter(w)}
+	defer p.w.Flush()
+
+	p.header("nxlog4go_records_total", "counter", "Log records dispatched by level.")
+	names := make([]string, 0, len(m.Levels))
+	for name := range m.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p.sample("nxlog4go_records_total", m.Levels[name], "level", name)
+	}
+
+	p.header("nxlog4go_filter_records_total", "counter", "Log records dispatched to appenders by filter.")
+	for _, f := range m.Filters {
+		p.sample("nxlog4go_filter_records_total", f.Records, "filter", f.Name)
+	}
+
+	p.appenders(m, "nxlog4go_appender_bytes_total", "counter", "Encoded bytes written by appender.",
+		func(a *driver.AppenderMetrics) (interface{}, bool) { return a.Bytes, true })
+	p.appenders(m, "nxlog4go_appender_errors_total", "counter", "Write errors by appender.",
+		func(a *driver.AppenderMetrics) (interface{}, bool) { return a.Errors, true })
+	p.appenders(m, "nxlog4go_appender_rotations_total", "counter", "Files rotated by appender.",
+		func(a *driver.AppenderMetrics) (interface{}, bool) { return a.Rotations, true })
+	p.appenders(m, "nxlog4go_appender_dropped_total", "counter", "Log records dropped by appender when the queue overflows.",
+		func(a *driver.AppenderMetrics) (interface{}, bool) { return a.Dropped, true })
+	p.appenders(m, "nxlog4go_appender_queue_length", "gauge", "Log records queued by appender.",
+		func(a *driver.AppenderMetrics) (interface{}, bool) { return a.Queue, a.Queue >= 0 })
+}
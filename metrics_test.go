@@ -0,0 +1,87 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// statsApp reports fixed metrics, like asynchronous appenders.
+type statsApp struct {
+	queueApp
+}
+
+func (a *statsApp) Stats() driver.Stats { return driver.Stats{Bytes: 10, Errors: 1} }
+func (a *statsApp) Pending() int        { return 2 }
+func (a *statsApp) Dropped() uint64     { return 3 }
+
+func TestMetrics(t *testing.T) {
+	l := NewLogger(INFO).SetOutput(nil)
+	l.AddFilter("app", INFO, &statsApp{})
+
+	for i := 0; i < 3; i++ {
+		l.Error("message")
+	}
+	l.Debug("disabled")
+	// Other loggers are not counted
+	NewLogger(INFO).SetOutput(nil).Error("other")
+	// Named children and clones are counted
+	child := l.Named("child")
+	child.Error("child")
+	l.Clone().Warn("clone")
+
+	m := l.Metrics()
+	if got, want := m.Levels["ERROR"], uint64(4); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	if got, want := m.Levels["WARN"], uint64(1); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	if got, want := child.Metrics().Levels["ERROR"], uint64(1); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	if len(m.Filters) != 1 || len(m.Filters[0].Appenders) != 1 {
+		t.Fatalf("Unexpected metrics %+v", m)
+	}
+	// The filter is inherited by the named child and the clone
+	if got, want := m.Filters[0].Records, uint64(5); got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	a := m.Filters[0].Appenders[0]
+	if a.Name != "app" || a.Bytes != 10 || a.Errors != 1 || a.Queue != 2 || a.Dropped != 3 {
+		t.Errorf("Unexpected appender metrics %+v", a)
+	}
+
+	h := NewMetricsHandler(l)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		"# TYPE nxlog4go_records_total counter\n",
+		`nxlog4go_records_total{level="ERROR"} 4` + "\n",
+		`nxlog4go_filter_records_total{filter="app"} 5` + "\n",
+		`nxlog4go_appender_bytes_total{appender="app",type=""} 10` + "\n",
+		`nxlog4go_appender_errors_total{appender="app",type=""} 1` + "\n",
+		`nxlog4go_appender_dropped_total{appender="app",type=""} 3` + "\n",
+		`nxlog4go_appender_queue_length{appender="app",type=""} 2` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Missing %q in\n%s", want, body)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest("POST", "/", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Status %d, %s", rr.Code, rr.Body.String())
+	}
+}
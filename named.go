@@ -0,0 +1,182 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// namedMu protects the children of all loggers.
+var namedMu = new(sync.Mutex)
+
+// Named returns the named logger of the standard logger. See (*Logger).Named.
+func Named(name string) *Logger {
+	return std.Named(name)
+}
+
+// Named returns the named descendant logger. Names are dot separated, such
+// as "db.pool", whose parent is "db", whose parent is the logger itself.
+// The same name always returns the same logger.
+//
+// A named logger writes through the output writer and the layout of its
+// root logger. Its level and filters are inherited from the nearest ancestor
+// unless overridden:
+//   - SetLevel, or Set("level", ...), overrides the level. Records below the
+//     level are dropped, and records at or above the level are written to
+//     the output writer and passed to filters, which still apply their own
+//     levels. ResetLevel restores inheritance.
+//   - Attach or SetFilters overrides the inherited filters with its own.
+func (l *Logger) Named(name string) *Logger {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	p := l
+	for _, s := range strings.Split(name, ".") {
+		if s == "" {
+			continue
+		}
+		c, ok := p.children[s]
+		if !ok {
+			full := s
+			if p.name != "" {
+				full = p.name + "." + s
+			}
+			c = &Logger{
+				mu:      p.mu,
+				name:    full,
+				parent:  p,
+				prefix:  full,
+				caller:  p.caller,
+				stdf:    p.stdf,
+				filters: newFilterSnapshot(nil),
+				counts:  &levelCounts{parent: p.counts},
+
+				flushTimeout: p.flushTimeout,
+			}
+			if p.children == nil {
+				p.children = make(map[string]*Logger)
+			}
+			p.children[s] = c
+		}
+		p = c
+	}
+	return p
+}
+
+// Name returns the full name of the logger. The root logger has no name.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Parent returns the parent of a named logger, or nil for a root logger.
+func (l *Logger) Parent() *Logger {
+	return l.parent
+}
+
+// Level returns the effective level of the logger.
+func (l *Logger) Level() int {
+	if t := l.threshold(); t != nil {
+		return t.Level()
+	}
+	return l.stdf.level.Level()
+}
+
+// SetLevel sets the level of the logger. For a root logger, it is the
+// output level. For a named logger, it overrides the inherited level.
+func (l *Logger) SetLevel(n int) {
+	if l.parent == nil {
+		l.stdf.level.SetLevel(n)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lv := l.ownLevel(); lv != nil {
+		lv.SetLevel(n)
+		return
+	}
+	l.setOwnLevel(driver.NewAtomicLevel(n))
+}
+
+// ResetLevel removes the level of a named logger, so the level is
+// inherited from the parent again.
+func (l *Logger) ResetLevel() {
+	if l.parent == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setOwnLevel(nil)
+}
+
+// ownLevel returns the level of the logger, or nil if it is inherited.
+func (l *Logger) ownLevel() *driver.AtomicLevel {
+	lv, _ := l.level.Load().(*driver.AtomicLevel)
+	return lv
+}
+
+// setOwnLevel sets the level of a named logger. A nil level inherits the
+// level of the parent. The logger must be locked.
+func (l *Logger) setOwnLevel(lv *driver.AtomicLevel) {
+	l.level.Store(lv)
+	driver.InvalidateLevels()
+}
+
+// threshold returns the level of the nearest named logger which has one,
+// or nil if the level is inherited from the root logger.
+func (l *Logger) threshold() *driver.AtomicLevel {
+	for p := l; p != nil; p = p.parent {
+		if lv := p.ownLevel(); lv != nil {
+			return lv
+		}
+	}
+	return nil
+}
+
+// outputs returns the filters of the logger, or of the nearest ancestor
+// which has filters. The map should not be changed.
+func (l *Logger) outputs() map[string]*driver.Filter {
+	for p := l; p != nil; p = p.parent {
+		if m := p.filters.load(); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+// stdEnabled returns true if the level should be written to the output
+// writer of the logger.
+func (l *Logger) stdEnabled(level int) bool {
+	if t := l.threshold(); t != nil {
+		return l.stdf.active() && !driver.LevelBelow(level, t.Level())
+	}
+	return l.stdf.enabled(level)
+}
+
+// walk calls fn for the logger and all its named descendants.
+func (l *Logger) walk(fn func(*Logger)) {
+	namedMu.Lock()
+	loggers := []*Logger{l}
+	for i := 0; i < len(loggers); i++ {
+		for _, c := range loggers[i].children {
+			loggers = append(loggers, c)
+		}
+	}
+	namedMu.Unlock()
+
+	for _, c := range loggers {
+		fn(c)
+	}
+}
+
+// Shutdown flushes and closes the filters of the standard logger and of all
+// named loggers. Filters are removed from the loggers. It blocks until done,
+// see ShutdownContext for a deadline.
+func Shutdown() {
+	std.walk(func(l *Logger) {
+		l.Close()
+	})
+}
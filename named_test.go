@@ -0,0 +1,81 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ccpaging/nxlog4go/driver"
+	"github.com/ccpaging/nxlog4go/patt"
+)
+
+type closeApp struct {
+	buf    *bytes.Buffer
+	closed bool
+}
+
+func (a *closeApp) Open(dsn string, args ...interface{}) (driver.Appender, error) { return a, nil }
+func (a *closeApp) Enabled(r *driver.Recorder) bool                               { return true }
+func (a *closeApp) Write(b []byte) (int, error)                                   { return a.buf.Write(b) }
+func (a *closeApp) Close()                                                        { a.closed = true }
+func (a *closeApp) SetOptions(args ...interface{}) driver.Appender                { return a }
+func (a *closeApp) Set(k string, v interface{}) error                             { return nil }
+
+func TestNamedLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	root := NewLogger(INFO).SetOptions("format", "%P %L %M").SetOutput(buf)
+
+	pool := root.Named("db.pool")
+	if pool != root.Named("db").Named("pool") {
+		t.Errorf("The same name should return the same logger")
+	}
+	if got, want := pool.Name(), "db.pool"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	pool.Debug("hidden")
+	root.Named("db").SetLevel(DEBUG)
+	pool.Debug("inherited")
+	root.Debug("root")
+	pool.SetLevel(ERROR)
+	pool.Warn("overridden")
+	pool.ResetLevel()
+	pool.Warn("restored")
+	if got, want := buf.String(), "db.pool DEBG inherited\ndb.pool WARN restored\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestNamedLoggerFilters(t *testing.T) {
+	root := NewLogger(INFO).SetOutput(nil)
+	rootApp := &closeApp{buf: new(bytes.Buffer)}
+	root.AddFilter("app", INFO, rootApp)
+	root.Filters()["app"].Layout = patt.NewLayout("%P %M")
+
+	child := root.Named("child")
+	child.Info("inherited")
+
+	ownApp := &closeApp{buf: new(bytes.Buffer)}
+	grand := child.Named("grand")
+	grand.AddFilter("own", INFO, ownApp)
+	grand.Filters()["own"].Layout = patt.NewLayout("%P %M")
+	grand.Info("overridden")
+	child.Named("grand.great").Info("inherited")
+
+	if got, want := rootApp.buf.String(), "child inherited\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+	if got, want := ownApp.buf.String(), "child.grand overridden\nchild.grand.great inherited\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	root.walk(func(l *Logger) { l.Close() })
+	if !rootApp.closed || !ownApp.closed {
+		t.Errorf("All filters should be closed")
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package nxlog4go
+
+const raceEnabled = false
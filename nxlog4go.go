@@ -80,6 +80,8 @@ package nxlog4go
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ccpaging/nxlog4go/cast"
 	"github.com/ccpaging/nxlog4go/driver"
@@ -100,12 +102,32 @@ const (
 // log messages are written. Each logging operation makes a single call to
 // the Writer's Write method. A Logger can be used simultaneously from
 // multiple goroutines; it guarantees to serialize access to the Writer.
+//
+// Dispatching does not lock the logger, except for writing to the output
+// writer. Filters, levels, processors and the error handler are read from
+// atomically published values, which are replaced under the lock.
 type Logger struct {
+	levelCache uint64 // the minimum enabled level and its generation; first for alignment. See minLevel
+	needsCache uint64 // the attributes needed and its generation. See needs
+
 	mu      *sync.Mutex // ensures atomic writes; protects the following fields
 	prefix  string      // prefix to write at beginning of each line
-	caller  bool        // enable or disable calling runtime.Caller(...)
+	caller  bool        // enable or disable recording the caller, if a layout needs it
 	stdf    *stdFilter
-	filters map[string]*driver.Filter // a collection of Filter
+	filters *filterSnapshot // a collection of Filter; shared by clones
+
+	processors atomic.Value // driver.Processors, processors before dispatching
+	errHandler atomic.Value // handlerBox, handles errors of the output writer and appenders
+
+	name     string             // the full name of a named logger
+	parent   *Logger            // the parent of a named logger
+	level    atomic.Value       // *driver.AtomicLevel, the level of a named logger; nil inherits the parent's
+	children map[string]*Logger // named child loggers, protected by namedMu
+
+	config *LoggerConfig // the last applied configuration
+	counts *levelCounts  // the number of log recorders dispatched. See Metrics
+
+	flushTimeout time.Duration // the timeout of Sync
 }
 
 const (
@@ -119,7 +141,8 @@ func NewLogger(level int) *Logger {
 		mu:      new(sync.Mutex),
 		caller:  true,
 		stdf:    newStdFilter(level),
-		filters: make(map[string]*driver.Filter),
+		filters: newFilterSnapshot(make(map[string]*driver.Filter)),
+		counts:  new(levelCounts),
 	}
 }
 
@@ -128,13 +151,22 @@ func NewLogger(level int) *Logger {
 //	Using owner prefix and runtime caller switch.
 //  Running in the parallel go routines and packages is safe.
 func (l *Logger) Clone() *Logger {
-	return &Logger{
+	c := &Logger{
 		mu:      l.mu,
 		prefix:  l.prefix,
 		caller:  l.caller,
 		stdf:    l.stdf,
 		filters: l.filters,
+		name:    l.name,
+		parent:  l.parent,
+		counts:  &levelCounts{parent: l.counts},
+
+		flushTimeout: l.flushTimeout,
 	}
+	c.level.Store(l.ownLevel())
+	c.processors.Store(l.ownProcessors())
+	c.errHandler.Store(l.ownErrorHandler())
+	return c
 }
 
 // Copy copies the all filters of a logger.
@@ -142,6 +174,7 @@ func (l *Logger) Copy(src *Logger) {
 	l.mu = src.mu
 	l.stdf = src.stdf
 	l.filters = src.filters
+	driver.InvalidateLevels()
 }
 
 // SetOptions sets name-value pair options.
@@ -160,6 +193,7 @@ func (l *Logger) SetOptions(args ...interface{}) *Logger {
 //	caller - Enable or disable the runtime caller function
 //	fields - Select Entry with field pairs or values
 //	level  - The output level
+//	flushTimeout - The timeout of Sync, such as "3s"
 //
 // layout options...
 //
@@ -185,7 +219,25 @@ func (l *Logger) Set(k string, v interface{}) (err error) {
 		}
 	case "level":
 		if n, err = Level(INFO).IntE(v); err == nil {
-			l.stdf.level = n
+			if l.parent == nil {
+				l.stdf.level.SetLevel(n)
+			} else if lv := l.ownLevel(); lv == nil {
+				l.setOwnLevel(driver.NewAtomicLevel(n))
+			} else {
+				lv.SetLevel(n)
+			}
+		}
+	case "flushTimeout":
+		if s, err = cast.ToString(v); err == nil {
+			var d time.Duration
+			if d, err = time.ParseDuration(s); err == nil {
+				l.flushTimeout = d
+			}
+		} else {
+			var i64 int64
+			if i64, err = cast.ToSeconds(v); err == nil {
+				l.flushTimeout = time.Duration(i64) * time.Second
+			}
 		}
 	default:
 		return l.stdf.lo.Set(k, v)
@@ -206,62 +258,127 @@ func (l *Logger) SetLayout(layout driver.Layout) *Logger {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.stdf.lo = layout
+	driver.InvalidateLevels()
 	return l
 }
 
-// SetFilters sets the output filters for the logger.
-func (l *Logger) SetFilters(filters map[string]*driver.Filter) *Logger {
+// AtomicLevel returns the output level of the logger. It is safe to change
+// the level at runtime. It can be shared with filters as their Enabler.
+func (l *Logger) AtomicLevel() *driver.AtomicLevel {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.filters = filters
+	return l.stdf.level
+}
+
+// SetAtomicLevel sets the output level of the logger.
+func (l *Logger) SetAtomicLevel(level *driver.AtomicLevel) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if level != nil {
+		l.stdf.level = level
+		l.stdf.publish()
+	}
 	return l
 }
 
-// Filters returns the output filters for the logger.
-func (l *Logger) Filters() map[string]*driver.Filter {
+// SetFilters sets the output filters for the logger. The map is copied.
+// A nil map makes a named logger inherit the filters of its parent.
+func (l *Logger) SetFilters(filters map[string]*driver.Filter) *Logger {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return l.filters
+	if filters == nil && l.parent == nil {
+		filters = make(map[string]*driver.Filter)
+	}
+	l.filters.store(copyFilters(filters))
+	return l
+}
+
+// Filters returns the output filters for the logger, which may be inherited
+// from the parent. The map is a snapshot, which should not be changed.
+func (l *Logger) Filters() map[string]*driver.Filter {
+	return l.outputs()
 }
 
 // With creates a child logger and adds structured context to it. Args added
 // to the child don't affect the parent, and vice versa.
+//
+// The child is allocated before the level is checked. Unlike the logging
+// methods of Logger, it is not free at disabled levels, so create it once
+// on hot paths.
 func (l *Logger) With(args ...interface{}) *Entry {
 	// do not using with(args) which may create a slicer
 	return NewEntry(l).With(args...)
 }
 
+// WithFields creates a child logger and adds typed fields to it. See With.
+func (l *Logger) WithFields(fields ...Field) *Entry {
+	return NewEntry(l).WithFields(fields...)
+}
+
 // Enable sets the standard filter's Enabler to deny all,
 // or restores the default at/above level enabler.
 func (l *Logger) Enable(enable bool) *Logger {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.stdf.enb = enable
+	l.stdf.publish()
 	return l
 }
 
+// enabled returns false if the level is below the minimum level of the
+// logger, which is cached, so disabled levels are rejected cheaply.
 func (l *Logger) enabled(level int) bool {
-	if l.stdf.enabled(level) {
-		return true
+	return !driver.LevelBelow(level, l.minLevel())
+}
+
+// enabledAt returns true if a log recorder at the given level passes
+// the standard filter or the Enabler of any filter.
+func (l *Logger) enabledAt(level int) bool {
+	if !l.enabled(level) {
+		return false
 	}
 
-	if len(l.filters) > 0 {
+	if l.stdEnabled(level) {
 		return true
 	}
 
+	r := &driver.Recorder{Level: level}
+	for _, f := range l.outputs() {
+		if f != nil && (f.Enabler == nil || f.Enabler.Enabled(r)) {
+			return true
+		}
+	}
 	return false
 }
 
-// Dispatch encodes a log recorder to bytes and writes it.
+// Dispatch encodes a log recorder to bytes and writes it. The logger is
+// locked only for writing to the output writer. Filters lock themselves
+// where needed.
 func (l *Logger) Dispatch(r *driver.Recorder) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	if t := l.threshold(); t != nil && driver.LevelBelow(r.Level, t.Level()) {
+		return
+	}
 
-	if l.stdf.enabled(r.Level) {
-		l.stdf.dispatch(r)
+	if !l.process(r) {
+		return
+	}
+	l.counts.add(r.Level)
+
+	if l.stdEnabled(r.Level) {
+		l.mu.Lock()
+		var err error
+		if l.stdf.out != nil {
+			err = l.stdf.dispatch(r)
+		}
+		l.mu.Unlock()
+		if err != nil {
+			if h := l.errorHandler(); h != nil {
+				h.HandleError(stdfName, "write", err)
+			}
+		}
 	}
 
-	for _, f := range l.filters {
+	for _, f := range l.outputs() {
 		if f != nil {
 			f.Dispatch(r)
 		}
@@ -273,18 +390,13 @@ func (l *Logger) Dispatch(r *driver.Recorder) {
 // guarantee that all log messages are written.
 //
 // Notice: Close() removes all filters (and thus all appenders) except "stdout"
-// from the logger.
+// from the logger. Filters inherited by a named logger are not closed.
 func (l *Logger) Close() {
 	l.mu.Lock()
-	defer l.mu.Unlock()
+	filters := l.detachAll()
+	l.mu.Unlock()
 
-	for name, f := range l.filters {
-		if name == stdfName {
-			continue
-		}
-		if f != nil {
-			f.Close()
-		}
-		delete(l.filters, name)
+	for _, f := range filters {
+		f.Close()
 	}
 }
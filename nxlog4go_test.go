@@ -103,7 +103,7 @@ func TestLogger(t *testing.T) {
 	if l == nil {
 		t.Fatalf("NewLogger should never return nil")
 	}
-	if l.stdf.level != WARN {
+	if l.stdf.level.Level() != WARN {
 		t.Fatalf("NewLogger produced invalid logger (incorrect level)")
 	}
 
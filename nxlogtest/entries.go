@@ -0,0 +1,106 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlogtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	l4g "github.com/ccpaging/nxlog4go"
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// Entry is a captured log recorder. Unlike the recorder, which is
+// borrowed, an entry can be kept after logging.
+type Entry struct {
+	Level   int
+	Prefix  string
+	Message string
+	Source  string // the file of the caller, or "" if the caller is disabled
+	Line    int
+	Created time.Time
+	Fields  map[string]interface{} // the values and the typed fields. See driver.Recorder.Fields
+	Index   []string               // the keys of Fields in order
+}
+
+func newEntry(r *driver.Recorder) Entry {
+	e := Entry{
+		Level:   r.Level,
+		Prefix:  r.Prefix,
+		Message: r.Message,
+		Created: r.Created,
+	}
+	e.Source, e.Line = r.Caller()
+	e.Fields, e.Index = r.Fields()
+	return e
+}
+
+// String returns the entry as "LEVEL message key=value ...".
+func (e Entry) String() string {
+	var sb strings.Builder
+	sb.WriteString(l4g.Level(e.Level).Name())
+	sb.WriteByte(' ')
+	sb.WriteString(e.Message)
+	for _, k := range e.Index {
+		fmt.Fprintf(&sb, " %s=%v", k, e.Fields[k])
+	}
+	return sb.String()
+}
+
+// Entries is a slice of captured entries.
+type Entries []Entry
+
+// String returns the entries, one per line.
+func (es Entries) String() string {
+	var sb strings.Builder
+	for _, e := range es {
+		sb.WriteString(e.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Messages returns the messages of the entries.
+func (es Entries) Messages() []string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Message
+	}
+	return msgs
+}
+
+func (es Entries) filter(fn func(e *Entry) bool) Entries {
+	var out Entries
+	for i := range es {
+		if fn(&es[i]) {
+			out = append(out, es[i])
+		}
+	}
+	return out
+}
+
+// FilterLevel returns the entries at the level.
+func (es Entries) FilterLevel(level int) Entries {
+	return es.filter(func(e *Entry) bool {
+		return e.Level == level
+	})
+}
+
+// FilterMessageContains returns the entries whose message contains s.
+func (es Entries) FilterMessageContains(s string) Entries {
+	return es.filter(func(e *Entry) bool {
+		return strings.Contains(e.Message, s)
+	})
+}
+
+// FilterField returns the entries with the field whose value deeply
+// equals to value. Notice the value of a typed field, such as
+// driver.Int64, is int64.
+func (es Entries) FilterField(key string, value interface{}) Entries {
+	return es.filter(func(e *Entry) bool {
+		v, ok := e.Fields[key]
+		return ok && reflect.DeepEqual(v, value)
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+// Package nxlogtest provides an in-memory appender which captures log
+// recorders, and helpers to query and assert them in unit tests.
+//
+//	log, obs := nxlogtest.New(l4g.DEBUG)
+//	log.Warn("disk %s is full", "/var")
+//	obs.AssertLogged(t, l4g.WARN, "is full")
+//
+// NewT returns a logger which writes to the output of t, so the logs are
+// shown only for failing tests. See TestingAppender.
+package nxlogtest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	l4g "github.com/ccpaging/nxlog4go"
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// Observer is an appender which keeps the entries of all log recorders
+// in memory. It is safe for concurrent use.
+type Observer struct {
+	mu      sync.Mutex
+	entries Entries
+}
+
+// NewObserver creates an empty observer.
+func NewObserver() *Observer {
+	return &Observer{}
+}
+
+// New creates a logger at the level, without the output writer, which
+// writes to a new observer.
+func New(level int) (*l4g.Logger, *Observer) {
+	o := NewObserver()
+	l := l4g.NewLogger(level).SetOutput(nil).AddFilter("nxlogtest", level, o)
+	return l, o
+}
+
+// Open creates a new observer. The dsn is ignored.
+func (*Observer) Open(dsn string, args ...interface{}) (driver.Appender, error) {
+	o := NewObserver()
+	for i := 0; i+1 < len(args); i += 2 {
+		k, _ := args[i].(string)
+		if err := o.Set(k, args[i+1]); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Set returns error. An observer has no options.
+func (o *Observer) Set(k string, v interface{}) error {
+	return fmt.Errorf("unknown option name %s, value %#v of type %T", k, v, v)
+}
+
+// Needs returns driver.AttrCaller, so the source of entries is recorded
+// if the logger enables the caller. See driver.AttrsNeeder.
+func (o *Observer) Needs() driver.Attrs {
+	return driver.AttrCaller
+}
+
+// Enabled captures the log recorder, and returns false.
+func (o *Observer) Enabled(r *driver.Recorder) bool {
+	e := newEntry(r)
+	o.mu.Lock()
+	o.entries = append(o.entries, e)
+	o.mu.Unlock()
+	return false
+}
+
+// Write is nothing to do here.
+func (o *Observer) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+// Close is nothing to do here. The entries are kept.
+func (o *Observer) Close() {}
+
+// Len returns the number of captured entries.
+func (o *Observer) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.entries)
+}
+
+// All returns a copy of all captured entries.
+func (o *Observer) All() Entries {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append(Entries(nil), o.entries...)
+}
+
+// TakeAll returns all captured entries, and removes them.
+func (o *Observer) TakeAll() Entries {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	es := o.entries
+	o.entries = nil
+	return es
+}
+
+// FilterLevel returns the entries at the level. See Entries.FilterLevel.
+func (o *Observer) FilterLevel(level int) Entries {
+	return o.All().FilterLevel(level)
+}
+
+// FilterMessageContains returns the entries whose message contains s.
+// See Entries.FilterMessageContains.
+func (o *Observer) FilterMessageContains(s string) Entries {
+	return o.All().FilterMessageContains(s)
+}
+
+// FilterField returns the entries with the field. See Entries.FilterField.
+func (o *Observer) FilterField(key string, value interface{}) Entries {
+	return o.All().FilterField(key, value)
+}
+
+/** Assertions **/
+
+// AssertLogged fails the test if no entry at the level has a message
+// containing msg.
+func (o *Observer) AssertLogged(t testing.TB, level int, msg string) {
+	t.Helper()
+	if len(o.FilterLevel(level).FilterMessageContains(msg)) == 0 {
+		t.Errorf("No %s entry contains %q in\n%s", l4g.Level(level).Name(), msg, o.All())
+	}
+}
+
+// AssertNotLogged fails the test if any entry at the level has a message
+// containing msg.
+func (o *Observer) AssertNotLogged(t testing.TB, level int, msg string) {
+	t.Helper()
+	if es := o.FilterLevel(level).FilterMessageContains(msg); len(es) > 0 {
+		t.Errorf("Unexpected %s entries contain %q:\n%s", l4g.Level(level).Name(), msg, es)
+	}
+}
+
+// AssertField fails the test if no entry has the field.
+func (o *Observer) AssertField(t testing.TB, key string, value interface{}) {
+	t.Helper()
+	if len(o.FilterField(key, value)) == 0 {
+		t.Errorf("No entry has field %s=%#v in\n%s", key, value, o.All())
+	}
+}
+
+// AssertLen fails the test if the number of captured entries is not n.
+func (o *Observer) AssertLen(t testing.TB, n int) {
+	t.Helper()
+	if es := o.All(); len(es) != n {
+		t.Errorf("Expected %d entries, found %d:\n%s", n, len(es), es)
+	}
+}
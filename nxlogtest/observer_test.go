@@ -0,0 +1,69 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlogtest
+
+import (
+	"strings"
+	"testing"
+
+	l4g "github.com/ccpaging/nxlog4go"
+)
+
+// failTB records failures instead of failing the test.
+type failTB struct {
+	testing.TB
+	failed bool
+}
+
+func (t *failTB) Helper()                                   {}
+func (t *failTB) Errorf(format string, args ...interface{}) { t.failed = true }
+
+func TestObserver(t *testing.T) {
+	log, obs := New(l4g.INFO)
+	log.Debug("disabled")
+	log.Info("hello %s", "world")
+	l4g.NewEntry(log).With("user", "alice").WithFields(l4g.Int64("n", 3)).Warn("slow request")
+	log.Error("failed")
+
+	obs.AssertLen(t, 3)
+	obs.AssertLogged(t, l4g.INFO, "hello world")
+	obs.AssertLogged(t, l4g.WARN, "slow")
+	obs.AssertNotLogged(t, l4g.DEBUG, "disabled")
+	obs.AssertField(t, "user", "alice")
+	obs.AssertField(t, "n", int64(3))
+
+	if got, want := len(obs.FilterLevel(l4g.ERROR)), 1; got != want {
+		t.Errorf("   got %d", got)
+		t.Errorf("  want %d", want)
+	}
+	es := obs.FilterField("user", "alice")
+	if len(es) != 1 || es[0].Message != "slow request" || es[0].Level != l4g.WARN {
+		t.Errorf("Unexpected entries %s", es)
+	}
+	if !strings.HasSuffix(es[0].Source, "observer_test.go") || es[0].Line == 0 {
+		t.Errorf("Unexpected source %s:%d", es[0].Source, es[0].Line)
+	}
+	if got, want := es.String(), "WARN slow request user=alice n=3\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	ft := &failTB{}
+	obs.AssertLogged(ft, l4g.INFO, "missing")
+	if !ft.failed {
+		t.Errorf("AssertLogged should fail")
+	}
+	ft = &failTB{}
+	obs.AssertNotLogged(ft, l4g.ERROR, "failed")
+	if !ft.failed {
+		t.Errorf("AssertNotLogged should fail")
+	}
+
+	if got, want := strings.Join(obs.TakeAll().Messages(), ","), "hello world,slow request,failed"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+	if obs.Len() != 0 {
+		t.Errorf("TakeAll should remove the entries")
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlogtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	l4g "github.com/ccpaging/nxlog4go"
+	"github.com/ccpaging/nxlog4go/driver"
+	"github.com/ccpaging/nxlog4go/patt"
+)
+
+// TestingAppender writes log recorders to the output of t, so the logs are
+// shown only for failing tests, or with "go test -v". It is closed by
+// t.Cleanup, and drops log recorders after the test finishes.
+//
+// The logs are written by t.Output if it is supported (Go 1.25 or later),
+// which adds no source location. The layout encodes the real source of the
+// log recorder with %S:%N, which is recorded by the logger from its caller.
+// Otherwise, the logs are written by t.Log, whose prefix is the file and
+// line of the logger's internals.
+type TestingAppender struct {
+	mu     sync.Mutex // ensures atomic writes; protects the following fields
+	t      testing.TB
+	level  driver.AtomicLevel
+	layout driver.Layout // format entry for output
+	closed bool
+}
+
+// NewTestingAppender creates an appender writing through t, with the
+// layout options, such as "format". The default format is
+// patt.FormatConsole.
+func NewTestingAppender(t testing.TB, args ...interface{}) *TestingAppender {
+	ta := &TestingAppender{
+		t:      t,
+		layout: patt.NewLayout(patt.FormatConsole),
+	}
+	ta.SetOptions(args...)
+	t.Cleanup(ta.Close)
+	return ta
+}
+
+// NewT creates a logger at the level, without the output writer, which
+// writes through t. The args are the options of the appender. The filter
+// of the appender is detached by t.Cleanup.
+func NewT(t testing.TB, level int, args ...interface{}) *l4g.Logger {
+	ta := NewTestingAppender(t, args...)
+	f := &driver.Filter{
+		Name:    "testing",
+		Enabler: driver.NewAtomicLevel(level),
+		Apps:    []driver.Appender{ta},
+	}
+	l := l4g.NewLogger(level).SetOutput(nil).Attach(f)
+	t.Cleanup(func() {
+		l.Detach(f)
+	})
+	return l
+}
+
+// Open returns error. The appender is created by NewTestingAppender.
+func (*TestingAppender) Open(dsn string, args ...interface{}) (driver.Appender, error) {
+	return nil, fmt.Errorf("testing appender needs testing.TB")
+}
+
+// Layout returns the output layout for the appender.
+func (ta *TestingAppender) Layout() driver.Layout {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+	return ta.layout
+}
+
+// SetLayout sets the output layout for the appender.
+func (ta *TestingAppender) SetLayout(layout driver.Layout) *TestingAppender {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+	ta.layout = layout
+	driver.InvalidateLevels()
+	return ta
+}
+
+// Needs returns the attributes used by the layout. See driver.AttrsNeeder.
+func (ta *TestingAppender) Needs() driver.Attrs {
+	return driver.NeedsOf(ta.Layout())
+}
+
+// Level returns the output level of the appender.
+func (ta *TestingAppender) Level() int {
+	return ta.level.Level()
+}
+
+// SetLevel sets the output level of the appender. It is safe while logging.
+func (ta *TestingAppender) SetLevel(n int) {
+	ta.level.SetLevel(n)
+}
+
+// SetOptions sets name-value pair options.
+//
+// Return the appender.
+func (ta *TestingAppender) SetOptions(args ...interface{}) *TestingAppender {
+	ops, idx, _ := driver.ArgsToMap(args...)
+	for _, k := range idx {
+		ta.Set(k, ops[k])
+	}
+	return ta
+}
+
+// Set sets name-value option with:
+//
+//	level    - The output level
+//
+// Pattern layout options:
+//
+//	format   - Layout format string
+//	...
+//
+// Return error.
+func (ta *TestingAppender) Set(k string, v interface{}) (err error) {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	switch k {
+	case "level":
+		var n int
+		if n, err = l4g.Level(l4g.INFO).IntE(v); err == nil {
+			ta.level.SetLevel(n)
+		}
+	default:
+		return ta.layout.Set(k, v)
+	}
+	return
+}
+
+/* Bytes Buffer */
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// Enabled encodes the log recorder, writes it through t.Log, and returns
+// false.
+func (ta *TestingAppender) Enabled(r *driver.Recorder) bool {
+	if driver.LevelBelow(r.Level, ta.level.Level()) {
+		return false
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	if ta.closed {
+		return false
+	}
+	ta.layout.Encode(buf, r)
+	line := bytes.TrimRight(buf.Bytes(), "\r\n")
+	if o, ok := ta.t.(outputer); ok {
+		o.Output().Write(append(line, '\n'))
+		return false
+	}
+	ta.t.Helper()
+	// t.Log adds the line end
+	ta.t.Log(string(line))
+	return false
+}
+
+// outputer is implemented by testing.TB since Go 1.25.
+type outputer interface {
+	Output() io.Writer
+}
+
+// Write is nothing to do here.
+func (ta *TestingAppender) Write(b []byte) (int, error) {
+	return 0, nil
+}
+
+// Close stops writing through t, which must not be used after the test
+// finishes.
+func (ta *TestingAppender) Close() {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+	ta.closed = true
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlogtest
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	l4g "github.com/ccpaging/nxlog4go"
+)
+
+// logTB records logs and cleanups instead of writing to the test.
+type logTB struct {
+	testing.TB
+	logs     []string
+	cleanups []func()
+}
+
+func (t *logTB) Helper()                 {}
+func (t *logTB) Log(args ...interface{}) { t.logs = append(t.logs, fmt.Sprint(args...)) }
+func (t *logTB) Output() io.Writer       { return t }
+func (t *logTB) Cleanup(fn func())       { t.cleanups = append(t.cleanups, fn) }
+
+func (t *logTB) Write(b []byte) (int, error) {
+	t.logs = append(t.logs, string(b))
+	return len(b), nil
+}
+
+func (t *logTB) cleanup() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestNewT(t *testing.T) {
+	tb := &logTB{}
+	log := NewT(tb, l4g.INFO, "format", "%L %S:%N %M")
+
+	log.Debug("disabled")
+	_, _, line, _ := runtime.Caller(0)
+	log.Info("hello %s", "world")
+
+	// The source is of the caller, not of the appender
+	want := fmt.Sprintf("INFO nxlogtest/testing_test.go:%d hello world\n", line+1)
+	if len(tb.logs) != 1 || tb.logs[0] != want {
+		t.Errorf("   got %q", tb.logs)
+		t.Errorf("  want %q", want)
+	}
+
+	tb.cleanup()
+	log.Info("after the test")
+	if len(tb.logs) != 1 {
+		t.Errorf("Should not log after cleanup, got %q", tb.logs)
+	}
+	if len(log.Filters()) != 0 {
+		t.Errorf("Filter should be detached by cleanup")
+	}
+}
+
+func TestTestingAppender(t *testing.T) {
+	if os.Getenv("NXLOGTEST_CHILD") == "1" {
+		log := NewT(t, l4g.DEBUG, "format", "%L %S:%N %M")
+		log.Debug("written to the test output")
+		return
+	}
+
+	// Run the test itself with a real *testing.T, and check its output
+	cmd := exec.Command(os.Args[0], "-test.run=^TestTestingAppender$", "-test.v")
+	cmd.Env = append(os.Environ(), "NXLOGTEST_CHILD=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasSuffix(line, "written to the test output") {
+			continue
+		}
+		// Only the source of the caller, without the prefix of t.Log
+		if !strings.HasPrefix(line, "DEBG nxlogtest/testing_test.go:") {
+			t.Errorf("Unexpected location %q", line)
+		}
+		return
+	}
+	t.Errorf("Log not found in\n%s", out)
+}
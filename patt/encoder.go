@@ -7,7 +7,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/ccpaging/nxlog4go/driver"
@@ -15,28 +17,41 @@ import (
 
 // Cheap integer to fixed-width decimal ASCII. Give a negative width to avoid zero-padding.
 func itoa(buf *[]byte, i int, wid int) {
+	*buf = appendInt(*buf, i, wid)
+}
+
+// appendInt appends the fixed-width decimal of i to b, and returns the
+// extended slice. A stack buffer passed to appendInt does not escape.
+func appendInt(b []byte, i int, wid int) []byte {
 	// Assemble decimal in reverse order.
-	var b [20]byte
-	bp := len(b) - 1
+	var a [20]byte
+	bp := len(a) - 1
 	for i >= 10 || wid > 1 {
 		wid--
 		q := i / 10
-		b[bp] = byte('0' + i - q*10)
+		a[bp] = byte('0' + i - q*10)
 		bp--
 		i = q
 	}
 	// i < 10
-	b[bp] = byte('0' + i)
-	*buf = append(*buf, b[bp:]...)
+	a[bp] = byte('0' + i)
+	return append(b, a[bp:]...)
 }
 
 /** Encoder **/
 
 // Encoder defines log recorder field encoder interface for external packages extending.
+//
+// Encoders are shared by layouts, see DefaultEncoders, and Encode is called
+// concurrently by the goroutines of loggers and asynchronous appenders. So
+// an encoder should not be changed after created, and its caches, if any,
+// should be replaced atomically.
 type Encoder interface {
-	// Open opens a new Encoder according type.
+	// Open opens a new Encoder according type. The encoder itself is not
+	// changed.
 	NewEncoder(typ string) Encoder
-	// Encode serializes log recorder field to the bytes buffer.
+	// Encode serializes log recorder field to the bytes buffer. It must be
+	// safe for concurrent use.
 	Encode(out *bytes.Buffer, r *driver.Recorder)
 }
 
@@ -55,24 +70,37 @@ const (
 	modeZone
 )
 
+// cacheTime encodes date, time or zone. Options are not changed after
+// NewEncoder, and caches are replaced atomically, so the encoder is safe
+// for concurrent use.
 type cacheTime struct {
 	mode   int
 	encode func(buf *bytes.Buffer, t *time.Time)
 
-	y, mm, d  int
-	dateCache []byte
-	dayFirst  bool // true, dmy; false, mdy
-	sep       byte // '-', '/', '.'
-	century   bool
+	dayFirst bool // true, dmy; false, mdy
+	sep      byte // '-', '/', '.'
+	century  bool
+
+	nos bool
+	us  bool
 
-	h, m, s   int // the number of seconds elapsed since January 1, 1970 UTC
-	timeCache []byte
-	nos       bool
-	us        bool
+	zfmt string
 
-	loc       *time.Location
-	zoneCache []byte
-	zfmt      string
+	date  atomic.Value // *timeCache, keyed by year, month and day
+	clock atomic.Value // *timeCache, keyed by hour, minute and second
+	zone  atomic.Value // *zoneCache
+}
+
+// timeCache is an immutable cache of the encoded date or time.
+type timeCache struct {
+	key int
+	b   []byte
+}
+
+// zoneCache is an immutable cache of the encoded zone.
+type zoneCache struct {
+	loc *time.Location
+	b   []byte
 }
 
 // NewDateEncoder creates a new date encoder.
@@ -113,20 +141,31 @@ func (e *cacheTime) Encode(out *bytes.Buffer, r *driver.Recorder) {
 	e.encode(out, &r.Created)
 }
 
-/** Date Encoding **/
-
-func (e *cacheTime) writeCacheDate(buf *bytes.Buffer, t *time.Time) bool {
-	y, m, d := t.Date()
-	if y == e.y && int(m) == e.mm && d == e.d && e.dateCache != nil {
-		buf.Write(e.dateCache)
+// writeCache writes the cached bytes if the key matches.
+func writeCache(buf *bytes.Buffer, v *atomic.Value, key int) bool {
+	if c, _ := v.Load().(*timeCache); c != nil && c.key == key {
+		buf.Write(c.b)
 		return true
 	}
-	e.y, e.mm, e.d = y, int(m), d
 	return false
 }
 
+// storeCache writes the bytes, and replaces the cache with a copy.
+func storeCache(buf *bytes.Buffer, v *atomic.Value, key int, b []byte) {
+	buf.Write(b)
+	v.Store(&timeCache{key: key, b: append([]byte(nil), b...)})
+}
+
+/** Date Encoding **/
+
+func dateKey(t *time.Time) int {
+	y, m, d := t.Date()
+	return y*10000 + int(m)*100 + d
+}
+
 func (e *cacheTime) encoDate(buf *bytes.Buffer, t *time.Time) {
-	if e.writeCacheDate(buf, t) {
+	key := dateKey(t)
+	if writeCache(buf, &e.date, key) {
 		return
 	}
 
@@ -155,13 +194,12 @@ func (e *cacheTime) encoDate(buf *bytes.Buffer, t *time.Time) {
 	b[i+1] = byte('0' + y%10)
 	i += 2
 
-	e.dateCache = b[:i]
-
-	buf.Write(e.dateCache)
+	storeCache(buf, &e.date, key, b[:i])
 }
 
 func (e *cacheTime) encoCYMD(buf *bytes.Buffer, t *time.Time) {
-	if e.writeCacheDate(buf, t) {
+	key := dateKey(t)
+	if writeCache(buf, &e.date, key) {
 		return
 	}
 
@@ -181,9 +219,7 @@ func (e *cacheTime) encoCYMD(buf *bytes.Buffer, t *time.Time) {
 	b[8] = byte('0' + d/10)
 	b[9] = byte('0' + d%10)
 
-	e.dateCache = b[:10]
-
-	buf.Write(e.dateCache)
+	storeCache(buf, &e.date, key, b[:10])
 }
 
 func (e *cacheTime) setDate(typ string) {
@@ -211,48 +247,31 @@ func (e *cacheTime) setDate(typ string) {
 
 /** Time Encoding **/
 
-func (e *cacheTime) writeCacheTime(buf *bytes.Buffer, t *time.Time) bool {
-	h, m, s := t.Clock()
-	if s == e.s && m == e.m && h == e.h && e.timeCache != nil {
-		buf.Write(e.timeCache)
-		return true
-	}
-	e.h, e.m, e.s = h, m, s
-	return false
-}
-
 func (e *cacheTime) encoHMS(buf *bytes.Buffer, t *time.Time) {
-	if e.writeCacheTime(buf, t) {
-		return
-	}
-
 	hh, mm, ss := t.Clock()
-
-	var b [16]byte
-	b[0] = byte('0' + hh/10)
-	b[1] = byte('0' + hh%10)
-	b[2] = ':'
-	b[3] = byte('0' + mm/10)
-	b[4] = byte('0' + mm%10)
-
-	if e.nos {
-		e.timeCache = b[:5]
-		buf.Write(e.timeCache)
-		return
+	key := hh*10000 + mm*100 + ss
+	if !writeCache(buf, &e.clock, key) {
+		var b [16]byte
+		b[0] = byte('0' + hh/10)
+		b[1] = byte('0' + hh%10)
+		b[2] = ':'
+		b[3] = byte('0' + mm/10)
+		b[4] = byte('0' + mm%10)
+
+		n := 5
+		if !e.nos {
+			b[5] = ':'
+			b[6] = byte('0' + ss/10)
+			b[7] = byte('0' + ss%10)
+			n = 8
+		}
+		storeCache(buf, &e.clock, key, b[:n])
 	}
 
-	b[5] = ':'
-	b[6] = byte('0' + ss/10)
-	b[7] = byte('0' + ss%10)
-
-	e.timeCache = b[:8]
-	buf.Write(e.timeCache)
-
+	// The fraction is not cached
 	if e.us {
-		var us []byte
-		us = append(us, '.')
-		itoa(&us, t.Nanosecond()/1e3, 6)
-		buf.Write(us)
+		var a [16]byte
+		buf.Write(appendInt(append(a[:0], '.'), t.Nanosecond()/1e3, 6))
 	}
 }
 
@@ -264,8 +283,8 @@ func (e *cacheTime) rfc3339Nano(buf *bytes.Buffer, t *time.Time) {
 
 	e.encoHMS(buf, t)
 
-	var b []byte
-	itoa(&b, t.Nanosecond(), 9)
+	var a [16]byte
+	b := appendInt(a[:0], t.Nanosecond(), 9)
 
 	// trim '0'
 	n := len(b)
@@ -291,9 +310,8 @@ func (e *cacheTime) iso8601(buf *bytes.Buffer, t *time.Time) {
 
 	buf.WriteByte('.')
 
-	var b []byte
-	itoa(&b, t.Nanosecond()/1e6, 3)
-	buf.Write(b)
+	var a [16]byte
+	buf.Write(appendInt(a[:0], t.Nanosecond()/1e6, 3))
 
 	e.encoZone(buf, t)
 }
@@ -327,11 +345,12 @@ func (e *cacheTime) setTime(s string) {
 
 func (e *cacheTime) encoZone(buf *bytes.Buffer, t *time.Time) {
 	loc := t.Location()
-	if e.loc != loc || e.zoneCache == nil {
-		e.zoneCache = []byte(t.Format(e.zfmt))
-		e.loc = loc
+	c, _ := e.zone.Load().(*zoneCache)
+	if c == nil || c.loc != loc {
+		c = &zoneCache{loc: loc, b: []byte(t.Format(e.zfmt))}
+		e.zone.Store(c)
 	}
-	buf.Write(e.zoneCache)
+	buf.Write(c.b)
 }
 
 func (e *cacheTime) setZone(s string) {
@@ -382,7 +401,7 @@ func (*callerEncoder) NewEncoder(typ string) Encoder {
 }
 
 func (e *callerEncoder) Encode(buf *bytes.Buffer, r *driver.Recorder) {
-	s := r.Source
+	s, _ := r.Caller()
 
 	if len(s) <= 0 {
 		return
@@ -429,7 +448,7 @@ type fieldsEncoder struct {
 	deli  string
 	quote bool
 
-	encode func(out *bytes.Buffer, fields map[string]interface{}, index []string)
+	encode func(out *bytes.Buffer, r *driver.Recorder)
 }
 
 // NewFieldsEncoder creates a new fields encoder.
@@ -462,8 +481,7 @@ func (*fieldsEncoder) NewEncoder(typ string) Encoder {
 }
 
 func (e *fieldsEncoder) Encode(out *bytes.Buffer, r *driver.Recorder) {
-	fields, index := r.Fields()
-	e.encode(out, fields, index)
+	e.encode(out, r)
 }
 
 func (e *fieldsEncoder) encoKeyValue(out *bytes.Buffer, k string, v interface{}) {
@@ -481,33 +499,70 @@ func (e *fieldsEncoder) encoKeyValue(out *bytes.Buffer, k string, v interface{})
 	out.WriteString(s)
 }
 
-func (e *fieldsEncoder) encoStd(out *bytes.Buffer, fields map[string]interface{}, index []string) {
-	if len(fields) <= 0 {
-		return
-	}
-
-	if len(index) > 1 {
-		for _, k := range index {
-			out.WriteString(e.sep)
-			e.encoKeyValue(out, k, fields[k])
+func (e *fieldsEncoder) encoStd(out *bytes.Buffer, r *driver.Recorder) {
+	if len(r.Values) > 0 {
+		fields, index := driver.LazyArgsToMap(r.Values...)
+		if len(index) > 1 {
+			for _, k := range index {
+				out.WriteString(e.sep)
+				e.encoKeyValue(out, k, fields[k])
+			}
+		} else {
+			for k, v := range fields {
+				out.WriteString(e.sep)
+				e.encoKeyValue(out, k, v)
+			}
 		}
-		return
 	}
 
-	for k, v := range fields {
+	var buf [64]byte
+	for _, f := range r.Typed {
 		out.WriteString(e.sep)
-		e.encoKeyValue(out, k, v)
+		out.WriteString(f.Key)
+		out.WriteString(e.deli)
+		b := f.AppendText(buf[:0])
+		if e.quote {
+			b = strconv.AppendQuote(b[len(b):], string(b))
+		}
+		out.Write(b)
 	}
 }
 
-func (e *fieldsEncoder) encoJSON(out *bytes.Buffer, fields map[string]interface{}, index []string) {
-	if len(fields) <= 0 {
+func (e *fieldsEncoder) encoJSON(out *bytes.Buffer, r *driver.Recorder) {
+	if len(r.Values) <= 0 && len(r.Typed) <= 0 {
 		return
 	}
 
 	out.WriteString(",\"Fields\":")
-	encoder := json.NewEncoder(out)
-	encoder.Encode(fields)
+	if len(r.Typed) <= 0 {
+		fields, _ := r.Fields()
+		encoder := json.NewEncoder(out)
+		encoder.Encode(fields)
+		return
+	}
+
+	out.WriteByte('{')
+	n := 0
+	if len(r.Values) > 0 {
+		fields, index := driver.LazyArgsToMap(r.Values...)
+		for _, k := range index {
+			if n > 0 {
+				out.WriteByte(',')
+			}
+			writeJSONKey(out, k)
+			writeJSONValue(out, fields[k])
+			n++
+		}
+	}
+	for _, f := range r.Typed {
+		if n > 0 {
+			out.WriteByte(',')
+		}
+		writeJSONKey(out, f.Key)
+		writeJSONField(out, f)
+		n++
+	}
+	out.WriteByte('}')
 }
 
 /* Values Encoder */
@@ -515,7 +570,7 @@ type valuesEncoder struct {
 	sep   string
 	quote bool
 
-	encode func(out *bytes.Buffer, values []interface{})
+	encode func(out *bytes.Buffer, r *driver.Recorder)
 }
 
 // NewValuesEncoder creates a new data fields encoder.
@@ -525,7 +580,7 @@ func NewValuesEncoder(typ string) Encoder {
 }
 
 func (e *valuesEncoder) Encode(out *bytes.Buffer, r *driver.Recorder) {
-	e.encode(out, r.Values)
+	e.encode(out, r)
 }
 
 func (*valuesEncoder) NewEncoder(typ string) Encoder {
@@ -548,12 +603,9 @@ func (*valuesEncoder) NewEncoder(typ string) Encoder {
 	return e
 }
 
-func (e *valuesEncoder) encoStd(out *bytes.Buffer, values []interface{}) {
-	if len(values) <= 0 {
-		return
-	}
+func (e *valuesEncoder) encoStd(out *bytes.Buffer, r *driver.Recorder) {
 	var s string
-	for _, v := range values {
+	for _, v := range r.Values {
 		out.WriteString(e.sep)
 		if e.quote {
 			s = fmt.Sprintf("%q", v)
@@ -567,14 +619,130 @@ func (e *valuesEncoder) encoStd(out *bytes.Buffer, values []interface{}) {
 		}
 		out.WriteString(s)
 	}
+
+	// The typed fields are written as "key value".
+	var buf [64]byte
+	for _, f := range r.Typed {
+		out.WriteString(e.sep)
+		b := buf[:0]
+		if e.quote {
+			b = strconv.AppendQuote(b, f.Key)
+		} else {
+			b = append(b, f.Key...)
+		}
+		out.Write(b)
+		out.WriteString(e.sep)
+		b = f.AppendText(buf[:0])
+		if e.quote {
+			b = strconv.AppendQuote(b[len(b):], string(b))
+		}
+		out.Write(b)
+	}
 }
 
-func (e *valuesEncoder) encoJSON(out *bytes.Buffer, values []interface{}) {
-	if len(values) <= 0 {
+func (e *valuesEncoder) encoJSON(out *bytes.Buffer, r *driver.Recorder) {
+	if len(r.Values) <= 0 && len(r.Typed) <= 0 {
 		return
 	}
 
 	out.WriteString(",\"Values\":")
-	encoder := json.NewEncoder(out)
-	encoder.Encode(values)
+	if len(r.Typed) <= 0 {
+		encoder := json.NewEncoder(out)
+		encoder.Encode(r.Values)
+		return
+	}
+
+	out.WriteByte('[')
+	for i, v := range r.Values {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		writeJSONValue(out, v)
+	}
+	for i, f := range r.Typed {
+		if i > 0 || len(r.Values) > 0 {
+			out.WriteByte(',')
+		}
+		writeJSONString(out, f.Key)
+		out.WriteByte(',')
+		writeJSONField(out, f)
+	}
+	out.WriteByte(']')
+}
+
+/* JSON helpers */
+
+func writeJSONKey(out *bytes.Buffer, k string) {
+	writeJSONString(out, k)
+	out.WriteByte(':')
+}
+
+func writeJSONValue(out *bytes.Buffer, v interface{}) {
+	switch x := v.(type) {
+	case string:
+		writeJSONString(out, x)
+	case error:
+		writeJSONString(out, x.Error())
+	case func() string:
+		writeJSONString(out, x())
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			writeJSONString(out, fmt.Sprint(v))
+			return
+		}
+		out.Write(b)
+	}
+}
+
+// writeJSONField writes the typed field value without boxing it.
+func writeJSONField(out *bytes.Buffer, f driver.Field) {
+	var buf [64]byte
+	switch f.Type {
+	case driver.StringType:
+		writeJSONString(out, f.String)
+	case driver.Int64Type, driver.DurationType:
+		out.Write(strconv.AppendInt(buf[:0], f.Integer, 10))
+	case driver.BoolType:
+		out.Write(f.AppendText(buf[:0]))
+	case driver.TimeType:
+		out.WriteByte('"')
+		out.Write(f.AppendText(buf[:0]))
+		out.WriteByte('"')
+	default:
+		writeJSONValue(out, f.Value())
+	}
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString writes the string with quotes, escaping as JSON does.
+func writeJSONString(out *bytes.Buffer, s string) {
+	out.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' {
+			continue
+		}
+		out.WriteString(s[start:i])
+		switch c {
+		case '"', '\\':
+			out.WriteByte('\\')
+			out.WriteByte(c)
+		case '\n':
+			out.WriteString("\\n")
+		case '\r':
+			out.WriteString("\\r")
+		case '\t':
+			out.WriteString("\\t")
+		default:
+			out.WriteString("\\u00")
+			out.WriteByte(hexDigits[c>>4])
+			out.WriteByte(hexDigits[c&0xf])
+		}
+		start = i + 1
+	}
+	out.WriteString(s[start:])
+	out.WriteByte('"')
 }
@@ -4,6 +4,7 @@ package patt
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 	"time"
 
@@ -50,6 +51,7 @@ func TestTimeEncoder(t *testing.T) {
 		{"", "15:04:05"},
 		{"hhmm", "15:04"},
 		{"hms", "15:04:05"},
+		{"hms.us", "15:04:05.000000"},
 		{"rfc3339nano", time.RFC3339Nano},
 		{"iso8601", "2006-01-02T15:04:05.000Z0700"},
 	}
@@ -171,3 +173,61 @@ func BenchmarkTimeFormat(b *testing.B) {
 	}
 	b.StopTimer()
 }
+
+func TestTypedFieldsEncoder(t *testing.T) {
+	r := &driver.Recorder{
+		Values: []interface{}{"int", 3},
+		Typed: []driver.Field{
+			driver.String("short", "abc"),
+			driver.Int64("n", -42),
+			driver.Bool("ok", true),
+			driver.Duration("d", 1500*time.Millisecond),
+			driver.Err(errors.New("boom")),
+		},
+	}
+
+	out := new(bytes.Buffer)
+	NewFieldsEncoder("").Encode(out, r)
+	want := " int=3 short=abc n=-42 ok=true d=1.5s error=boom"
+	if got := out.String(); got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	out.Reset()
+	NewFieldsEncoder("json").Encode(out, r)
+	want = `,"Fields":{"int":3,"short":"abc","n":-42,"ok":true,"d":1500000000,"error":"boom"}`
+	if got := out.String(); got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	out.Reset()
+	NewValuesEncoder("").Encode(out, r)
+	want = " int 3 short abc n -42 ok true d 1.5s error boom"
+	if got := out.String(); got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	out.Reset()
+	NewValuesEncoder("json").Encode(out, r)
+	want = `,"Values":["int",3,"short","abc","n",-42,"ok",true,"d",1500000000,"error","boom"]`
+	if got := out.String(); got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestTypedFieldsJSONEscape(t *testing.T) {
+	r := &driver.Recorder{
+		Typed: []driver.Field{driver.String("s", "a\"b\\c\n\x01")},
+	}
+	out := new(bytes.Buffer)
+	NewFieldsEncoder("json").Encode(out, r)
+	want := `,"Fields":{"s":"a\"b\\c\n\u0001"}`
+	if got := out.String(); got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
@@ -39,7 +39,8 @@ type Encoders struct {
 	ValuesEncoder  Encoder
 }
 
-// DefaultEncoders allows users to configure the concrete encoders.
+// DefaultEncoders allows users to configure the concrete encoders. The
+// encoders are shared by all layouts created later.
 var DefaultEncoders Encoders = Encoders{
 	BeginColorizer: NewNopEncoder(),
 	EndColorizer:   NewNopEncoder(),
@@ -52,7 +53,8 @@ var DefaultEncoders Encoders = Encoders{
 	ValuesEncoder:  NewValuesEncoder(""),
 }
 
-// PatternLayout formats log Recorder.
+// PatternLayout formats log Recorder. Encode is safe for concurrent use,
+// while Set should not be called during encoding.
 type PatternLayout struct {
 	verbs [][]byte // Split the format string into pieces by % signs
 
@@ -61,6 +63,8 @@ type PatternLayout struct {
 
 	Encoders
 
+	options []interface{} // name-value pairs which have been set
+
 	// DEPRECATED. Compatible with log4go
 	_encodeDate Encoder
 	_encodeTime Encoder // DEPRECATED
@@ -90,6 +94,9 @@ func NewLayout(format string, args ...interface{}) *PatternLayout {
 		_encodeDate: NewDateEncoder("mdy"),
 		_encodeTime: NewTimeEncoder("hhmm"),
 	}
+	if format != "" {
+		lo.options = []interface{}{"format", format}
+	}
 	lo.SetOptions(args...)
 	return lo
 }
@@ -165,7 +172,27 @@ func (lo *PatternLayout) setEncoder(k string, v interface{}) error {
 //  %t - Time (15:04). Replacing with setting "timeEncoder" as "hhmm".
 //
 // Ignores other unknown format codes
-func (lo *PatternLayout) Set(k string, v interface{}) (err error) {
+func (lo *PatternLayout) Set(k string, v interface{}) error {
+	if err := lo.set(k, v); err != nil {
+		return err
+	}
+	if k == "pattern" {
+		k = "format"
+	}
+	if s, err := cast.ToString(v); err == nil {
+		v = s
+	}
+	lo.options = driver.SetOption(lo.options, k, v)
+	return nil
+}
+
+// Options returns the options which have been set, in the form of
+// SetOptions arguments. Default options are not included.
+func (lo *PatternLayout) Options() []interface{} {
+	return append([]interface{}(nil), lo.options...)
+}
+
+func (lo *PatternLayout) set(k string, v interface{}) (err error) {
 	var (
 		s  string
 		ok bool
@@ -174,6 +201,8 @@ func (lo *PatternLayout) Set(k string, v interface{}) (err error) {
 	case "format", "pattern":
 		if s, err = cast.ToString(v); err == nil && len(s) > 0 {
 			lo.verbs = formatToVerbs(s)
+			// Loggers cache the attributes needed. See Needs
+			driver.InvalidateLevels()
 		}
 	case "lineEnd":
 		if s, err = cast.ToString(v); err == nil {
@@ -208,11 +237,13 @@ func (lo *PatternLayout) Set(k string, v interface{}) (err error) {
 }
 
 func (lo *PatternLayout) encode(out *bytes.Buffer, r *driver.Recorder) {
-	t := r.Created
+	// The recorder of time encoders
+	tr := r
 	if lo.utc {
-		t = t.UTC()
+		tr = driver.NewRecorder()
+		tr.Created = r.Created.UTC()
+		defer tr.Release()
 	}
-	tr := &driver.Recorder{Created: t}
 
 	// Iterate over the pieces, replacing known formats
 	// Split the string into pieces by % signs
@@ -239,17 +270,16 @@ func (lo *PatternLayout) encode(out *bytes.Buffer, r *driver.Recorder) {
 		case 'L':
 			lo.LevelEncoder.Encode(out, r)
 		case 'l':
-			var b []byte
-			itoa(&b, int(r.Level), -1)
-			out.Write(b)
+			var a [20]byte
+			out.Write(appendInt(a[:0], int(r.Level), -1))
 		case 'P':
 			out.WriteString(r.Prefix)
 		case 'S':
 			lo.CallerEncoder.Encode(out, r)
 		case 'N':
-			var b []byte
-			itoa(&b, r.Line, -1)
-			out.Write(b)
+			_, line := r.Caller()
+			var a [20]byte
+			out.Write(appendInt(a[:0], line, -1))
 		case 'M':
 			out.WriteString(r.Message)
 		case 'F':
@@ -265,6 +295,16 @@ func (lo *PatternLayout) encode(out *bytes.Buffer, r *driver.Recorder) {
 	}
 }
 
+// Needs returns driver.AttrCaller if the format contains %S or %N.
+func (lo *PatternLayout) Needs() (n driver.Attrs) {
+	for i, piece := range lo.verbs {
+		if i > 0 && len(piece) > 0 && (piece[0] == 'S' || piece[0] == 'N') {
+			n |= driver.AttrCaller
+		}
+	}
+	return
+}
+
 // Encode log Recorder to bytes buffer.
 func (lo *PatternLayout) Encode(out *bytes.Buffer, r *driver.Recorder) int {
 	if r == nil {
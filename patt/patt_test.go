@@ -5,6 +5,9 @@ package patt
 import (
 	"bytes"
 	"encoding/json"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -59,6 +62,42 @@ func TestPatternLayout(t *testing.T) {
 	}
 }
 
+func TestPatternLayoutConcurrent(t *testing.T) {
+	tests := []struct {
+		layout *PatternLayout
+		format string
+	}{
+		{NewLayout(FormatDefault, "utc", true), "[2006/01/02 15:04:05 MST] [] (source:0) message\n"},
+		{NewLayout(FormatDefault, "utc", true), "[2006/01/02 15:04:05 MST] [] (source:0) message\n"},
+		{NewLayout("%T %M", "timeEncoder", "hms.us"), "15:04:05.000000 message\n"},
+		{NewLayout("%T", "timeEncoder", "rfc3339nano", "lineEnd", ""), time.RFC3339Nano},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			out := new(bytes.Buffer)
+			for j := 0; j < 1000; j++ {
+				// Cross seconds and days, and hit caches in between
+				now := created.Add(time.Duration(j*(i+1)) * 7 * time.Hour / 1000)
+				r := &driver.Recorder{Source: "source", Message: "message", Created: now}
+				for _, tt := range tests {
+					tt.layout.Encode(out, r)
+					if got, want := out.String(), now.Format(tt.format); got != want {
+						t.Errorf("   got %q", got)
+						t.Errorf("  want %q", want)
+						return
+					}
+					out.Reset()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
 func BenchmarkPatternLayout(b *testing.B) {
 	const updateEvery = 1
 	r := &driver.Recorder{
@@ -120,3 +159,30 @@ BenchmarkPatternLayout-4                 1840386               633 ns/op
 BenchmarkJson-4                           428559              2817 ns/op
 BenchmarkJsonLayout-4                    1411684               839 ns/op
 */
+
+func TestPatternLayoutNeeds(t *testing.T) {
+	tests := []struct {
+		format string
+		want   driver.Attrs
+	}{
+		{FormatDefault, driver.AttrCaller},
+		{FormatShort, 0},
+		{"%M (line %N)", driver.AttrCaller},
+		{"100%% %M", 0},
+	}
+	for _, tt := range tests {
+		if got := NewLayout(tt.format).Needs(); got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.format, got, tt.want)
+		}
+	}
+
+	r := &driver.Recorder{Message: "message"}
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	r.PC = pcs[0]
+	out := new(bytes.Buffer)
+	NewLayout("%S:%N", "callerEncoder", "nopath").Encode(out, r)
+	if got := out.String(); !strings.HasPrefix(got, "patt_test.go:") {
+		t.Errorf("Caller should be resolved from PC, got %q", got)
+	}
+}
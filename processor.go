@@ -0,0 +1,54 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// AddProcessor appends processors to the logger. Processors enrich, rewrite
+// or drop log recorders before they are written to the output writer and
+// passed to filters. See driver.Processor.
+//
+// The processors of a named logger are called after the processors of
+// its ancestors. Processors are called concurrently without locking, and
+// should be safe for concurrent use.
+//
+// Returns the logger for chaining.
+func (l *Logger) AddProcessor(ps ...driver.Processor) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	own := l.ownProcessors()
+	l.processors.Store(append(own[:len(own):len(own)], ps...))
+	return l
+}
+
+// SetProcessors replaces the processors of the logger.
+func (l *Logger) SetProcessors(ps ...driver.Processor) *Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.processors.Store(append(driver.Processors(nil), ps...))
+	return l
+}
+
+// Processors returns the processors of the logger, without the processors
+// of its ancestors.
+func (l *Logger) Processors() driver.Processors {
+	return append(driver.Processors(nil), l.ownProcessors()...)
+}
+
+// ownProcessors returns the processors of the logger, which should not be
+// changed.
+func (l *Logger) ownProcessors() driver.Processors {
+	ps, _ := l.processors.Load().(driver.Processors)
+	return ps
+}
+
+// process calls the processors of the ancestors and the logger in order.
+// Return false if the recorder is dropped.
+func (l *Logger) process(r *driver.Recorder) bool {
+	if l.parent != nil && !l.parent.process(r) {
+		return false
+	}
+	return l.ownProcessors().Process(r)
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ccpaging/nxlog4go/driver"
+	"github.com/ccpaging/nxlog4go/patt"
+)
+
+func TestLoggerProcessors(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%P %L %M%F").SetOutput(buf)
+	l.AddProcessor(driver.NewFieldsProcessor(String("version", "1.0")))
+
+	pool := l.Named("db.pool")
+	pool.AddProcessor(driver.ProcessorFunc(func(r *driver.Recorder) bool {
+		if strings.HasPrefix(r.Message, "secret") {
+			return false
+		}
+		r.Message = strings.ToUpper(r.Message)
+		return true
+	}))
+
+	app := &closeApp{buf: new(bytes.Buffer)}
+	l.AddFilter("app", INFO, app)
+	f := l.Filters()["app"]
+	f.Layout = patt.NewLayout("%M%F")
+	f.Processors = driver.Processors{driver.NewFieldsProcessor(String("filter", "app"))}
+
+	l.Info("root")
+	pool.Info("pool")
+	pool.Info("secret")
+
+	if got, want := buf.String(), " INFO root version=1.0\ndb.pool INFO POOL version=1.0\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+	if got, want := app.buf.String(), "root version=1.0 filter=app\nPOOL version=1.0 filter=app\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestProcessorsConfig(t *testing.T) {
+	os.Setenv("TEST_POD_NAME", "web-0")
+	defer os.Unsetenv("TEST_POD_NAME")
+
+	lc := &LoggerConfig{
+		Processors: []*ProcessorConfig{
+			{Type: "fields", Properties: []NameValue{{Name: "version", Value: "${TEST_BUILD_VERSION:-dev}"}}},
+			{Type: "env", Properties: []NameValue{{Name: "hostname", Value: ""}, {Name: "pid", Value: ""}, {Name: "pod", Value: "TEST_POD_NAME"}}},
+			{Type: "unknown"},
+		},
+	}
+	if ds := ValidateConfiguration(lc); len(ds) != 1 || ds[0].Property != "processor" {
+		t.Errorf("Validate unknown processor: %v", ds)
+	}
+
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%M%F").SetOutput(buf)
+	errs := l.LoadConfiguration(lc)
+	if len(errs) != 1 {
+		t.Errorf("Load errors: %v", errs)
+	}
+	l.Info("message")
+	if got, want := buf.String(), "message version=dev pod=web-0\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	buf.Reset()
+	lc.Processors = lc.Processors[:1]
+	l.ReloadConfiguration(lc)
+	l.Info("reloaded")
+	if got, want := buf.String(), "reloaded version=dev\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
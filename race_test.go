@@ -0,0 +1,6 @@
+//go:build race
+
+package nxlog4go
+
+// The race detector drops pooled objects randomly.
+const raceEnabled = true
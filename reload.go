@@ -0,0 +1,447 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ccpaging/nxlog4go/cast"
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// clone returns a copy of the configuration, with default filter tags.
+func (lc *LoggerConfig) clone() *LoggerConfig {
+	c := &LoggerConfig{Version: lc.Version}
+	for _, fc := range lc.Filters {
+		if fc == nil {
+			continue
+		}
+		f := *fc
+		if f.Tag == "" {
+			f.Tag = f.Type
+		}
+		f.Properties = append([]NameValue(nil), fc.Properties...)
+		f.Processors = cloneProcessors(fc.Processors)
+		c.Filters = append(c.Filters, &f)
+	}
+	c.Loggers = append([]NameValue(nil), lc.Loggers...)
+	c.Processors = cloneProcessors(lc.Processors)
+	return c
+}
+
+func cloneProcessors(pcs []*ProcessorConfig) (c []*ProcessorConfig) {
+	for _, pc := range pcs {
+		if pc == nil {
+			continue
+		}
+		p := *pc
+		p.Properties = append([]NameValue(nil), pc.Properties...)
+		c = append(c, &p)
+	}
+	return
+}
+
+// ParseConfiguration parses the configuration content. The format is
+// detected from the file name extension, ".json" or ".xml". With other
+// extensions, content beginning with '{' is parsed as JSON, otherwise XML.
+func ParseConfiguration(name string, buf []byte) (*LoggerConfig, error) {
+	lc := new(LoggerConfig)
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		err = json.Unmarshal(buf, lc)
+	case ".xml":
+		err = xml.Unmarshal(buf, lc)
+	default:
+		if bytes.HasPrefix(bytes.TrimSpace(buf), []byte("{")) {
+			err = json.Unmarshal(buf, lc)
+		} else {
+			err = xml.Unmarshal(buf, lc)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse configuration %s. %v", name, err)
+	}
+	return lc, nil
+}
+
+// ReadConfigurationFile reads and parses the configuration file.
+// See ParseConfiguration.
+func ReadConfigurationFile(path string) (*LoggerConfig, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfiguration(path, buf)
+}
+
+// LoadConfigurationFile reads the configuration file, and applies it to
+// the standard logger. See (*Logger).LoadConfigurationFile.
+func LoadConfigurationFile(path string) []error {
+	return std.LoadConfigurationFile(path)
+}
+
+// LoadConfigurationFile reads the XML or JSON configuration file, and
+// applies it to the logger with ReloadConfiguration.
+func (l *Logger) LoadConfigurationFile(path string) (errs []error) {
+	lc, err := ReadConfigurationFile(path)
+	if err != nil {
+		return append(errs, fmt.Errorf("Error: %v", err))
+	}
+	return l.ReloadConfiguration(lc)
+}
+
+// ReloadConfiguration applies the configuration to the logger, comparing
+// with the last applied configuration by filter tag:
+//   - Filters which are removed or disabled are detached and closed.
+//   - Filters which are added, or whose type or dsn are changed, are opened.
+//     If opening fails, the old filter keeps working.
+//   - If the dsn or the level of a filter fails to expand, the old filter
+//     keeps working.
+//   - Filters whose properties are removed are opened again, so the removed
+//     properties are reset to defaults.
+//   - Otherwise, the changed level, properties and processors are set in
+//     place.
+//
+// The processors of the logger are replaced if they are changed.
+// Filters which are not created by configuration are not touched. Values
+// are expanded as LoadConfiguration.
+func (l *Logger) ReloadConfiguration(lc *LoggerConfig) (errs []error) {
+	if lc == nil {
+		return append(errs, fmt.Errorf("Warn: Logger configuration is NIL"))
+	}
+	raw := lc.clone()
+	lc, errs = lc.expand()
+
+	l.mu.Lock()
+	last := l.config
+	current := l.filters.load()
+	l.mu.Unlock()
+
+	// The last configuration is kept unexpanded, and expanded again to be
+	// compared. Its errors were reported when it was applied.
+	var (
+		managed = make(map[string]*FilterConfig) // expanded
+		lastRaw = make(map[string]*FilterConfig)
+	)
+	if last != nil {
+		expanded, _ := last.expand()
+		for i, fc := range expanded.Filters {
+			managed[fc.Tag] = fc
+			lastRaw[fc.Tag] = last.Filters[i]
+		}
+		last = expanded
+	}
+
+	var (
+		applied  = &LoggerConfig{Version: raw.Version, Loggers: raw.Loggers, Processors: raw.Processors}
+		keep     = make(map[string]bool)
+		updated  = make(map[string]*FilterConfig)
+		replaced = make(map[string]*driver.Filter)
+	)
+
+	for i, fc := range lc.Filters {
+		if fc.Type == "" {
+			errs = append(errs, fmt.Errorf("Warn: The type of Filter [%d] is not defined", i))
+			continue
+		}
+
+		enabled, err := cast.ToBool(fc.Enabled)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Trace: Disable filter [%s]. Error: %v", fc.Tag, err))
+		} else if !enabled {
+			errs = append(errs, fmt.Errorf("Trace: Disable filter [%s]", fc.Tag))
+		}
+
+		if fc.unresolved {
+			if lfc, ok := lastRaw[fc.Tag]; ok {
+				// Keep the old working filter
+				keep[fc.Tag] = true
+				applied.Filters = append(applied.Filters, lfc)
+			}
+			continue
+		}
+
+		if fc.Type == "loglog" {
+			errs = append(errs, setLogger(GetLogLog(), enabled, fc)...)
+			applied.Filters = append(applied.Filters, raw.Filters[i])
+			continue
+		} else if fc.Type == stdfName {
+			errs = append(errs, setLogger(l, enabled, fc)...)
+			applied.Filters = append(applied.Filters, raw.Filters[i])
+			continue
+		} else if !enabled {
+			continue
+		}
+
+		lfc, ok := managed[fc.Tag]
+		cur := current[fc.Tag]
+		if cur != nil && !ok {
+			errs = append(errs, fmt.Errorf("Warn: Filter [%s] existed, which is not created by configuration", fc.Tag))
+			continue
+		}
+		if cur != nil && lfc.Type == fc.Type && lfc.Dsn == fc.Dsn &&
+			!removesProperty(lastRaw[fc.Tag], raw.Filters[i]) {
+			updated[fc.Tag] = fc
+			keep[fc.Tag] = true
+			applied.Filters = append(applied.Filters, raw.Filters[i])
+			continue
+		}
+
+		f, e := loadFilter(fc)
+		errs = append(errs, e...)
+		if f == nil {
+			if cur != nil {
+				// Keep the old working filter
+				keep[fc.Tag] = true
+				applied.Filters = append(applied.Filters, lastRaw[fc.Tag])
+			}
+			continue
+		}
+		replaced[fc.Tag] = f
+		keep[fc.Tag] = true
+		applied.Filters = append(applied.Filters, raw.Filters[i])
+	}
+
+	var closing []*driver.Filter
+
+	l.mu.Lock()
+	l.updateFilters(func(m map[string]*driver.Filter) {
+		for _, fc := range lc.Filters {
+			if updated[fc.Tag] == fc {
+				errs = append(errs, updateFilter(m[fc.Tag], managed[fc.Tag], fc)...)
+			}
+		}
+		for tag, f := range replaced {
+			if old := m[tag]; old != nil {
+				closing = append(closing, old)
+			}
+			m[tag] = f
+		}
+		for tag := range managed {
+			if f := m[tag]; f != nil && !keep[tag] {
+				closing = append(closing, f)
+				delete(m, tag)
+				errs = append(errs, fmt.Errorf("Trace: Removed filter [%s]", tag))
+			}
+		}
+	})
+	l.config = applied
+	l.mu.Unlock()
+
+	for _, f := range closing {
+		f.Close()
+	}
+
+	if (last == nil && len(lc.Processors) > 0) ||
+		(last != nil && !reflect.DeepEqual(last.Processors, lc.Processors)) {
+		ps, e := loadProcessors("logger", lc.Processors)
+		errs = append(errs, e...)
+		l.SetProcessors(ps...)
+	}
+
+	errs = append(errs, setNamedLevels(l, lc.Loggers)...)
+	if last != nil {
+		names := make(map[string]bool)
+		for _, nv := range raw.Loggers {
+			names[nv.Name] = true
+		}
+		for _, nv := range last.Loggers {
+			if !names[nv.Name] {
+				l.Named(nv.Name).ResetLevel()
+			}
+		}
+	}
+	return
+}
+
+// removesProperty returns true if a property of the last filter
+// configuration is removed. The configurations are unexpanded, so a
+// property which fails to expand is not taken as removed.
+func removesProperty(last, fc *FilterConfig) bool {
+	names := make(map[string]bool, len(fc.Properties))
+	for _, prop := range fc.Properties {
+		names[prop.Name] = true
+	}
+	for _, prop := range last.Properties {
+		if !names[prop.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFilter sets the changed level, properties and processors of the filter.
+func updateFilter(f *driver.Filter, last, fc *FilterConfig) (errs []error) {
+	if f == nil {
+		return
+	}
+	f.Update(func(f *driver.Filter) {
+		if last.Level != fc.Level {
+			if lv := driver.FindLeveler(f.Enabler); lv != nil {
+				lv.SetLevel(Level(INFO).Int(fc.Level))
+			}
+		}
+
+		values := make(map[string]string, len(last.Properties))
+		for _, prop := range last.Properties {
+			values[prop.Name] = strings.Trim(prop.Value, " \r\n")
+		}
+		for _, prop := range fc.Properties {
+			if v, ok := values[prop.Name]; ok && v == strings.Trim(prop.Value, " \r\n") {
+				continue
+			}
+			if err := setFilterProperty(f, fc, prop); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if !reflect.DeepEqual(last.Processors, fc.Processors) {
+			var e []error
+			f.Processors, e = loadProcessors("filter ["+fc.Tag+"]", fc.Processors)
+			errs = append(errs, e...)
+		}
+	})
+	errs = append(errs, fmt.Errorf("Trace: Updated tag [%s], type [%s], dsn [%s]", fc.Tag, fc.Type, fc.Dsn))
+	return
+}
+
+// logLogErrors reports the configuration errors through the internal logger.
+func logLogErrors(errs []error) {
+	for _, err := range errs {
+		s := err.Error()
+		switch {
+		case strings.HasPrefix(s, "Trace: "):
+			LogLogTrace("%s", s[len("Trace: "):])
+		case strings.HasPrefix(s, "Warn: "):
+			LogLogWarn("%s", s[len("Warn: "):])
+		case strings.HasPrefix(s, "Error: "):
+			LogLogError("%s", s[len("Error: "):])
+		default:
+			LogLogError("%s", s)
+		}
+	}
+}
+
+// DefaultWatchInterval is the default polling interval of ConfigWatcher.
+var DefaultWatchInterval = 5 * time.Second
+
+// ConfigWatcher polls the modification time and the content hash of a
+// configuration file, and reloads the logger when the file is changed.
+// Errors are reported through the internal logger, see GetLogLog. If the
+// file can not be read or parsed, the old configuration keeps working.
+type ConfigWatcher struct {
+	log      *Logger
+	path     string
+	interval time.Duration
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	sum     uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// WatchConfigurationFile starts watching the configuration file of the
+// logger. The current content of the file is taken as loaded, so call
+// LoadConfigurationFile first. An interval <= 0 means DefaultWatchInterval.
+func (l *Logger) WatchConfigurationFile(path string, interval time.Duration) *ConfigWatcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w := &ConfigWatcher{
+		log:      l,
+		path:     path,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if fi, err := os.Stat(path); err == nil {
+		w.modTime, w.size = fi.ModTime(), fi.Size()
+		if buf, err := ioutil.ReadFile(path); err == nil {
+			w.sum = checksum(buf)
+		}
+	}
+	go w.run()
+	return w
+}
+
+func checksum(buf []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(buf)
+	return h.Sum64()
+}
+
+func (w *ConfigWatcher) run() {
+	defer close(w.done)
+
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			w.Check()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Check reloads the configuration if the file is changed.
+// Return true if the logger is reloaded.
+func (w *ConfigWatcher) Check() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fi, err := os.Stat(w.path)
+	if err != nil {
+		LogLogWarn("Watch %s. %v", w.path, err)
+		return false
+	}
+	if fi.ModTime().Equal(w.modTime) && fi.Size() == w.size {
+		return false
+	}
+	w.modTime, w.size = fi.ModTime(), fi.Size()
+
+	buf, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		LogLogWarn("Watch %s. %v", w.path, err)
+		return false
+	}
+	sum := checksum(buf)
+	if sum == w.sum {
+		return false
+	}
+	w.sum = sum
+
+	lc, err := ParseConfiguration(w.path, buf)
+	if err != nil {
+		LogLogError("Reload %s. %v", w.path, err)
+		return false
+	}
+	LogLogInfo("Reload %s", w.path)
+	logLogErrors(w.log.ReloadConfiguration(lc))
+	return true
+}
+
+// Close stops watching. It is safe to call Close concurrently.
+func (w *ConfigWatcher) Close() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	l4g "github.com/ccpaging/nxlog4go"
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+func filterLevel(t *testing.T, log *l4g.Logger, tag string) int {
+	f := log.Filters()[tag]
+	if f == nil {
+		t.Fatalf("Missing filter %q", tag)
+	}
+	return driver.FindLeveler(f.Enabler).Level()
+}
+
+func TestReloadConfiguration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nxlog4go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logging.json")
+
+	write := func(s string) {
+		if err := ioutil.WriteFile(path, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"filters": [
+		{"enabled": "true", "tag": "a", "type": "console", "level": "INFO"},
+		{"enabled": "true", "tag": "b", "type": "console", "level": "INFO"}
+	]}`)
+
+	log := l4g.NewLogger(l4g.INFO).SetOutput(nil)
+	defer log.Close()
+	log.LoadConfigurationFile(path)
+	a, b := log.Filters()["a"], log.Filters()["b"]
+	if a == nil || b == nil {
+		t.Fatalf("Missing filters, found %v", log.Filters())
+	}
+
+	w := log.WatchConfigurationFile(path, time.Hour)
+	defer w.Close()
+
+	write(`{"filters": [
+		{"enabled": "true", "tag": "a", "type": "console", "level": "ERROR"},
+		{"enabled": "true", "tag": "b", "type": "unknown", "level": "INFO"},
+		{"enabled": "true", "tag": "c", "type": "console", "level": "DEBUG"}
+	], "loggers": [{"name": "db", "value": "WARN"}]}`)
+	if !w.Check() {
+		t.Fatalf("Changed file should be reloaded")
+	}
+	if log.Filters()["a"] != a || filterLevel(t, log, "a") != l4g.ERROR {
+		t.Errorf("Filter a should be updated in place")
+	}
+	if log.Filters()["b"] != b {
+		t.Errorf("Filter b should be kept if opening fails")
+	}
+	if filterLevel(t, log, "c") != l4g.DEBUG {
+		t.Errorf("Filter c should be opened")
+	}
+	if log.Named("db").Level() != l4g.WARN {
+		t.Errorf("Named logger level should be set")
+	}
+
+	write(`{"filters": [{"enabled": "true", "tag": "a", "type": "console", "level": "ERROR"`)
+	if w.Check() {
+		t.Errorf("Bad file should not be reloaded")
+	}
+	if len(log.Filters()) != 3 {
+		t.Errorf("Old configuration should keep working, found %v", log.Filters())
+	}
+
+	write(`{"filters": [{"enabled": "false", "tag": "a", "type": "console", "level": "ERROR"}]}`)
+	if !w.Check() {
+		t.Fatalf("Changed file should be reloaded")
+	}
+	if len(log.Filters()) != 0 {
+		t.Errorf("Removed filters should be closed, found %v", log.Filters())
+	}
+	if log.Named("db").Level() != l4g.INFO {
+		t.Errorf("Named logger level should be inherited again")
+	}
+}
+
+func TestConfigWatcherCloseConcurrent(t *testing.T) {
+	log := l4g.NewLogger(l4g.INFO).SetOutput(nil)
+	w := log.WatchConfigurationFile(filepath.Join(os.TempDir(), "_nxlog4go_none.json"), time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestReloadConfigurationUnresolved(t *testing.T) {
+	os.Setenv("NXLOG4GO_RELOAD_LEVEL", "WARN")
+	defer os.Unsetenv("NXLOG4GO_RELOAD_LEVEL")
+
+	lc := &l4g.LoggerConfig{
+		Filters: []*l4g.FilterConfig{
+			{Enabled: "true", Tag: "a", Type: "console", Level: "${NXLOG4GO_RELOAD_LEVEL}"},
+		},
+	}
+	log := l4g.NewLogger(l4g.INFO).SetOutput(nil)
+	defer log.Close()
+	log.LoadConfiguration(lc)
+	a := log.Filters()["a"]
+	if a == nil {
+		t.Fatalf("Missing filter %q", "a")
+	}
+
+	// A missing variable does not turn off the working filter
+	os.Unsetenv("NXLOG4GO_RELOAD_LEVEL")
+	if errs := log.ReloadConfiguration(lc); len(errs) == 0 {
+		t.Errorf("Unresolved variable should be reported")
+	}
+	if log.Filters()["a"] != a || filterLevel(t, log, "a") != l4g.WARN {
+		t.Errorf("Filter a should be kept")
+	}
+}
+
+func TestReloadConfigurationRemovedProperty(t *testing.T) {
+	lc := &l4g.LoggerConfig{
+		Filters: []*l4g.FilterConfig{
+			{Enabled: "true", Tag: "a", Type: "console", Level: "INFO",
+				Properties: []l4g.NameValue{{Name: "sampleFirst", Value: "1"}, {Name: "suppressWindow", Value: "1s"}}},
+		},
+	}
+	log := l4g.NewLogger(l4g.INFO).SetOutput(nil)
+	defer log.Close()
+	log.LoadConfiguration(lc)
+	a := log.Filters()["a"]
+	if _, ok := a.Enabler.(*driver.Sampler); !ok || a.Suppressor == nil {
+		t.Fatalf("Filter a should be sampled and suppressed")
+	}
+
+	// Changing a property updates the filter in place
+	lc.Filters[0].Properties[1].Value = "2s"
+	log.ReloadConfiguration(lc)
+	if log.Filters()["a"] != a {
+		t.Errorf("Filter a should be updated in place")
+	}
+
+	// Removing properties resets them to defaults
+	lc.Filters[0].Properties = lc.Filters[0].Properties[:0]
+	log.ReloadConfiguration(lc)
+	b := log.Filters()["a"]
+	if b == a {
+		t.Fatalf("Filter a should be opened again")
+	}
+	if _, ok := b.Enabler.(*driver.Sampler); ok || b.Suppressor != nil {
+		t.Errorf("Removed properties should be reset")
+	}
+}
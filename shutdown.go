@@ -0,0 +1,84 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// Shutdown closes all filters of the logger concurrently, like Close, but
+// waits at most until the context is done. Filters are removed from the
+// logger.
+//
+// If some appenders are not closed in time, it returns *driver.ShutdownError,
+// which reports the appenders and the number of log recorders abandoned.
+// They keep closing in the background.
+func (l *Logger) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	filters := l.detachAll()
+	l.mu.Unlock()
+
+	return shutdownFilters(ctx, filters)
+}
+
+// ShutdownContext closes the filters of the standard logger and of all
+// named loggers concurrently, and waits at most until the context is done.
+// See (*Logger).Shutdown.
+func ShutdownContext(ctx context.Context) error {
+	var filters []*driver.Filter
+	std.walk(func(l *Logger) {
+		l.mu.Lock()
+		filters = append(filters, l.detachAll()...)
+		l.mu.Unlock()
+	})
+	return shutdownFilters(ctx, filters)
+}
+
+// detachAll removes all filters except "stdout" from the logger, and
+// returns them. The logger must be locked.
+func (l *Logger) detachAll() (filters []*driver.Filter) {
+	m := l.filters.load()
+	if len(m) == 0 {
+		return
+	}
+	keep := make(map[string]*driver.Filter)
+	for name, f := range m {
+		if name == stdfName {
+			keep[name] = f
+			continue
+		}
+		if f != nil {
+			filters = append(filters, f)
+		}
+	}
+	l.filters.store(keep)
+	return
+}
+
+func shutdownFilters(ctx context.Context, filters []*driver.Filter) error {
+	errs := make(chan error, len(filters))
+	for _, f := range filters {
+		go func(f *driver.Filter) {
+			errs <- f.Shutdown(ctx)
+		}(f)
+	}
+
+	se := new(driver.ShutdownError)
+	for range filters {
+		err := <-errs
+		if e, ok := err.(*driver.ShutdownError); ok {
+			se.Err = e.Err
+			se.Abandoned = append(se.Abandoned, e.Abandoned...)
+		}
+	}
+	if len(se.Abandoned) == 0 {
+		return nil
+	}
+	sort.Slice(se.Abandoned, func(i, j int) bool {
+		return se.Abandoned[i].Appender < se.Abandoned[j].Appender
+	})
+	return se
+}
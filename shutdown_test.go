@@ -0,0 +1,48 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+type stuckApp struct {
+	closeApp
+	release chan struct{}
+}
+
+func (a *stuckApp) Close() { <-a.release }
+
+func TestLoggerShutdown(t *testing.T) {
+	stuck := &stuckApp{closeApp{buf: new(bytes.Buffer)}, make(chan struct{})}
+	defer close(stuck.release)
+	app := &closeApp{buf: new(bytes.Buffer)}
+
+	l := NewLogger(INFO).SetOutput(nil)
+	l.AddFilter("app", INFO, app)
+	l.AddFilter("stuck", INFO, stuck)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := l.Shutdown(ctx)
+
+	var se *driver.ShutdownError
+	if !errors.As(err, &se) {
+		t.Fatalf("Shutdown should fail, found %v", err)
+	}
+	if len(se.Abandoned) != 1 || se.Abandoned[0].Appender != "stuck" || se.Abandoned[0].Records != -1 {
+		t.Errorf("Abandoned %v", se.Abandoned)
+	}
+	if !app.closed {
+		t.Errorf("Appender should be closed")
+	}
+	if len(l.Filters()) != 0 {
+		t.Errorf("Filters should be removed")
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// SlogHandler is a slog.Handler which sends slog records to a Logger.
+//
+// Every slog.Record is turned into a driver.Recorder and dispatched
+// through the Logger, so the logger's filters, appenders and layouts
+// still apply. Groups are encoded as nested fields.
+type SlogHandler struct {
+	log  *Logger
+	goas []groupOrAttrs
+}
+
+// groupOrAttrs holds either a group name or a list of attributes
+// added with WithGroup or WithAttrs.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewSlogHandler creates a slog.Handler which writes to the logger.
+//
+// Example: slog.SetDefault(slog.New(NewSlogHandler(log)))
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{log: l}
+}
+
+// slogLevel maps a slog level to a logger level.
+func slogLevel(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError+4:
+		return CRITICAL
+	case level >= slog.LevelError:
+		return ERROR
+	case level >= slog.LevelWarn:
+		return WARN
+	case level >= slog.LevelInfo:
+		return INFO
+	case level >= slog.LevelDebug+2:
+		return TRACE
+	case level >= slog.LevelDebug:
+		return DEBUG
+	case level >= slog.LevelDebug-4:
+		return FINE
+	}
+	return FINEST
+}
+
+// Enabled reports whether the logger emits log records at the given level.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.log.enabledAt(slogLevel(level))
+}
+
+// Handle converts the slog record to a log recorder and dispatches it.
+// Values of the registered context extractors are appended as fields.
+func (h *SlogHandler) Handle(ctx context.Context, sr slog.Record) error {
+	l := h.log
+
+	r := driver.NewRecorder()
+	defer r.Release()
+	r.Prefix = l.prefix
+	r.Level = slogLevel(sr.Level)
+	r.Message = sr.Message
+	r.Created = sr.Time
+	if r.Created.IsZero() {
+		r.Created = time.Now()
+	}
+
+	if l.caller {
+		r.PC = sr.PC
+	}
+
+	var attrs []slog.Attr
+	sr.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	r.Values = appendSlogValues(nil, h.goas, attrs)
+	r.Values = append(r.Values, contextValues(ctx)...)
+
+	l.Dispatch(r)
+	return nil
+}
+
+// WithAttrs returns a new handler whose attributes consist of
+// both the receiver's attributes and the arguments.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup returns a new handler with the given group appended to
+// the receiver's existing groups.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrAttrs{group: name})
+}
+
+func (h *SlogHandler) with(goa groupOrAttrs) *SlogHandler {
+	h2 := *h
+	h2.goas = make([]groupOrAttrs, len(h.goas)+1)
+	copy(h2.goas, h.goas)
+	h2.goas[len(h.goas)] = goa
+	return &h2
+}
+
+// appendSlogValues appends the handler attributes, groups and the record
+// attributes as name-value pairs. An open group collects everything after
+// it into a nested map. Empty groups are omitted.
+func appendSlogValues(kvs []interface{}, goas []groupOrAttrs, attrs []slog.Attr) []interface{} {
+	for i, goa := range goas {
+		if goa.group != "" {
+			m := make(map[string]interface{})
+			nestSlogValues(m, goas[i+1:], attrs)
+			if len(m) > 0 {
+				kvs = append(kvs, goa.group, m)
+			}
+			return kvs
+		}
+		for _, a := range goa.attrs {
+			kvs = appendSlogAttr(kvs, a)
+		}
+	}
+	for _, a := range attrs {
+		kvs = appendSlogAttr(kvs, a)
+	}
+	return kvs
+}
+
+func nestSlogValues(m map[string]interface{}, goas []groupOrAttrs, attrs []slog.Attr) {
+	kvs := appendSlogValues(nil, goas, attrs)
+	for i := 0; i+1 < len(kvs); i += 2 {
+		m[kvs[i].(string)] = kvs[i+1]
+	}
+}
+
+func appendSlogAttr(kvs []interface{}, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kvs
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		return append(kvs, a.Key, slogValue(a.Value))
+	}
+
+	group := a.Value.Group()
+	if len(group) == 0 {
+		return kvs
+	}
+	if a.Key == "" {
+		// Inline the attributes of a group with an empty key.
+		for _, ga := range group {
+			kvs = appendSlogAttr(kvs, ga)
+		}
+		return kvs
+	}
+	m := make(map[string]interface{}, len(group))
+	nestSlogValues(m, nil, group)
+	return append(kvs, a.Key, m)
+}
+
+func slogValue(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return v.Duration()
+	case slog.KindTime:
+		return v.Time()
+	}
+	return v.Any()
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(DEBUG).SetOptions("format", "%L %M%F").SetOutput(buf)
+	sl := slog.New(NewSlogHandler(l))
+
+	sl.Info("hello", "k1", "v1", "n", 3)
+	if got, want := buf.String(), "INFO hello k1=v1 n=3\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	buf.Reset()
+	sl.With("a", 1).WithGroup("g").With("b", 2).Warn("grouped", "c", 3)
+	if got, want := buf.String(), "WARN grouped a=1 g=map[b:2 c:3]\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	buf.Reset()
+	sl.WithGroup("empty").Error("no attrs")
+	if got, want := buf.String(), "EROR no attrs\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+
+	buf.Reset()
+	sl.Debug("debug")
+	if got, want := buf.String(), "DEBG debug\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestSlogHandlerContext(t *testing.T) {
+	RegisterContextExtractor("reqid", func(ctx context.Context) []interface{} {
+		if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+			return []interface{}{"reqid", id}
+		}
+		return nil
+	})
+	defer RegisterContextExtractor("reqid", nil)
+
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%L %M%F").SetOutput(buf)
+	sl := slog.New(NewSlogHandler(l))
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "42")
+	sl.InfoContext(ctx, "hello", "k", "v")
+	if got, want := buf.String(), "INFO hello k=v reqid=42\n"; got != want {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want %q", want)
+	}
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	l := NewLogger(WARN).SetOutput(new(bytes.Buffer))
+	h := NewSlogHandler(l)
+
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Errorf("Info should be disabled at WARN")
+	}
+	if !h.Enabled(context.Background(), slog.LevelError) {
+		t.Errorf("Error should be enabled at WARN")
+	}
+}
+
+func TestSlogHandlerCaller(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewLogger(INFO).SetOptions("format", "%S %M", "callerEncoder", "nopath").SetOutput(buf)
+	slog.New(NewSlogHandler(l)).Info("caller")
+	if got := buf.String(); !strings.HasPrefix(got, "slog_test.go ") {
+		t.Errorf("   got %q", got)
+		t.Errorf("  want prefix %q", "slog_test.go ")
+	}
+}
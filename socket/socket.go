@@ -16,13 +16,18 @@ import (
 
 // Appender is an Appender that sends output to an UDP/TCP server
 type Appender struct {
-	mu       sync.Mutex            // ensures atomic writes; protects the following fields
-	rec      chan *driver.Recorder // entry channel
+	stats driver.StatsCounter // bytes written and write errors; first for alignment
+
+	mu       sync.Mutex         // ensures atomic writes; protects the following fields
+	queue    *driver.Queue      // entry queue
+	flush    chan chan struct{} // flush request channel
+	exit     chan struct{}      // closed when run returns
 	runOnce  sync.Once
 	waitExit *sync.WaitGroup
 
-	level  int
+	level  driver.AtomicLevel
 	layout driver.Layout // format entry for output
+	errs   driver.ErrorReporter
 
 	proto    string
 	hostport string
@@ -45,7 +50,8 @@ func init() {
 // NewAppender creates a socket appender with proto and hostport.
 func NewAppender(proto, hostport string) *Appender {
 	return &Appender{
-		rec: make(chan *driver.Recorder, 32),
+		queue: driver.NewQueue(driver.DefaultQueueCapacity, driver.OverflowBlock),
+		flush: make(chan chan struct{}),
 
 		layout: patt.NewJSONLayout(),
 
@@ -70,6 +76,12 @@ func (*Appender) Open(dsn string, args ...interface{}) (driver.Appender, error)
 	return NewAppender(proto, hostport).SetOptions(args...), nil
 }
 
+// Validate checks the name-value option on a new appender, which does not
+// connect. See driver.Validator.
+func (*Appender) Validate(k string, v interface{}) error {
+	return NewAppender("udp", "127.0.0.1:12124").Set(k, v)
+}
+
 // Layout returns the output layout for the appender.
 func (sa *Appender) Layout() driver.Layout {
 	sa.mu.Lock()
@@ -82,9 +94,54 @@ func (sa *Appender) SetLayout(layout driver.Layout) *Appender {
 	sa.mu.Lock()
 	defer sa.mu.Unlock()
 	sa.layout = layout
+	driver.InvalidateLevels()
 	return sa
 }
 
+// Needs returns the attributes used by the layout. See driver.AttrsNeeder.
+func (sa *Appender) Needs() driver.Attrs {
+	return driver.NeedsOf(sa.Layout())
+}
+
+// Level returns the output level of the appender.
+func (sa *Appender) Level() int {
+	return sa.level.Level()
+}
+
+// SetLevel sets the output level of the appender. It is safe while logging.
+func (sa *Appender) SetLevel(n int) {
+	sa.level.SetLevel(n)
+}
+
+// SetErrorHandler sets the handler of dial and write errors.
+// See driver.ErrorHandlerSetter.
+func (sa *Appender) SetErrorHandler(name string, h driver.ErrorHandler) {
+	sa.errs.SetErrorHandler(name, h)
+}
+
+// Dsn returns the protocol and endpoint of the appender, like "udp://127.0.0.1:12124".
+func (sa *Appender) Dsn() string {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	return sa.proto + "://" + sa.hostport
+}
+
+// Options returns the current name-value pair options of the appender
+// and its layout. See Set.
+func (sa *Appender) Options() []interface{} {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+
+	opts := []interface{}{
+		"level", l4g.Level(sa.level.Level()).Name(),
+	}
+	opts = append(opts, sa.queue.Options()...)
+	if o, ok := sa.layout.(driver.Optioner); ok {
+		opts = append(opts, o.Options()...)
+	}
+	return opts
+}
+
 // SetOptions sets name-value pair options.
 //
 // Return the appender.
@@ -99,14 +156,18 @@ func (sa *Appender) SetOptions(args ...interface{}) *Appender {
 // Enabled encodes log Recorder and output it.
 func (sa *Appender) Enabled(r *driver.Recorder) bool {
 	// r.Level < fa.level
-	if !(r.Level >= sa.level) {
+	if driver.LevelBelow(r.Level, sa.level.Level()) {
 		return false
 	}
 
 	sa.runOnce.Do(func() {
 		sa.waitExit = &sync.WaitGroup{}
 		sa.waitExit.Add(1)
-		go sa.run(sa.waitExit)
+		exit := make(chan struct{})
+		sa.mu.Lock()
+		sa.exit = exit
+		sa.mu.Unlock()
+		go sa.run(sa.waitExit, exit)
 	})
 
 	// Write after closed
@@ -115,7 +176,7 @@ func (sa *Appender) Enabled(r *driver.Recorder) bool {
 		return false
 	}
 
-	sa.rec <- r
+	sa.queue.Put(r.Clone())
 	return false
 }
 
@@ -125,28 +186,85 @@ func (sa *Appender) Write(b []byte) (int, error) {
 	return 0, nil
 }
 
-func (sa *Appender) run(waitExit *sync.WaitGroup) {
+func (sa *Appender) drain() {
+	for {
+		select {
+		case r, ok := <-sa.queue.C():
+			if !ok {
+				return
+			}
+			sa.output(r)
+			r.Release()
+		default:
+			return
+		}
+	}
+}
+
+// Pending returns the number of queued log recorders. See driver.Pending.
+func (sa *Appender) Pending() int {
+	return sa.queue.Len()
+}
+
+// Dropped returns the number of log recorders dropped when the queue
+// overflows. See driver.Dropper.
+func (sa *Appender) Dropped() uint64 {
+	return sa.queue.Dropped()
+}
+
+// Stats returns the output statistics. See driver.Statser.
+func (sa *Appender) Stats() driver.Stats {
+	return sa.stats.Stats()
+}
+
+// Flush writes all queued log recorders. It blocks until done, or until
+// the appender is closed.
+func (sa *Appender) Flush() {
+	sa.mu.Lock()
+	exit := sa.exit
+	sa.mu.Unlock()
+
+	if exit != nil {
+		done := make(chan struct{})
+		select {
+		case sa.flush <- done:
+			<-done
+		case <-exit:
+			// Closed already
+		}
+	}
+}
+
+func (sa *Appender) run(waitExit *sync.WaitGroup, exit chan struct{}) {
+	defer close(exit)
+
 	for {
 		select {
-		case r, ok := <-sa.rec:
+		case done := <-sa.flush:
+			sa.drain()
+			close(done)
+
+		case r, ok := <-sa.queue.C():
 			if !ok {
 				waitExit.Done()
 				return
 			}
 			sa.output(r)
+			r.Release()
 		}
 	}
 }
 
 func (sa *Appender) closeChannel() {
 	// notify closing. See run()
-	close(sa.rec)
+	sa.queue.Close()
 	// waiting for running channel closed
 	sa.waitExit.Wait()
 	sa.waitExit = nil
 	// drain channel
-	for r := range sa.rec {
+	for r := range sa.queue.C() {
 		sa.output(r)
+		r.Release()
 	}
 }
 
@@ -175,6 +293,7 @@ func (sa *Appender) output(r *driver.Recorder) {
 		sa.sock, err = net.Dial(sa.proto, sa.hostport)
 		if err != nil {
 			l4g.LogLogError(err)
+			sa.errs.Report("dial", err)
 			return
 		}
 	}
@@ -185,9 +304,11 @@ func (sa *Appender) output(r *driver.Recorder) {
 
 	sa.layout.Encode(buf, r)
 
-	_, err = sa.sock.Write(buf.Bytes())
+	n, err := sa.sock.Write(buf.Bytes())
+	sa.stats.Wrote(n, err)
 	if err != nil {
 		l4g.LogLogError(err)
+		sa.errs.Report("write", err)
 		sa.sock.Close()
 		sa.sock = nil
 	}
@@ -195,6 +316,9 @@ func (sa *Appender) output(r *driver.Recorder) {
 
 // Set sets name-value option with:
 //  level    - The output level
+//  queue    - The capacity of the queue. The default is 32
+//  overflow - The overflow policy of the queue. "block" (default),
+//             "dropNewest", "dropOldest" or "timeout". See driver.Queue
 //
 // Pattern layout options:
 //	pattern	 - Layout format pattern
@@ -211,7 +335,7 @@ func (sa *Appender) Set(k string, v interface{}) (err error) {
 	case "level":
 		var n int
 		if n, err = l4g.Level(l4g.INFO).IntE(v); err == nil {
-			sa.level = n
+			sa.level.SetLevel(n)
 		}
 	case "protocol": // DEPRECATED. See Open function's dsn argument
 		if s, err = cast.ToString(v); err == nil && len(s) > 0 {
@@ -227,6 +351,8 @@ func (sa *Appender) Set(k string, v interface{}) (err error) {
 			}
 			sa.hostport = s
 		}
+	case "queue", "overflow", "overflowTimeout":
+		err = sa.queue.Set(k, v)
 	default:
 		return sa.layout.Set(k, v)
 	}
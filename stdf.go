@@ -4,26 +4,51 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ccpaging/nxlog4go/driver"
 	"github.com/ccpaging/nxlog4go/patt"
 )
 
+// stdFilter is the output writer of loggers. Fields are protected by the
+// logger's mutex, while the state checked before writing is published
+// atomically, so disabled levels are skipped without locking.
 type stdFilter struct {
-	level int // The log level
-	flag  int // properties compatible with go std log
+	level *driver.AtomicLevel // The log level
+	flag  int                 // properties compatible with go std log
 	enb   bool
 	lo    driver.Layout
 	out   io.Writer // destination for output
+
+	state atomic.Value // stdState
+}
+
+type stdState struct {
+	level *driver.AtomicLevel
+	on    bool // enabled, and the output writer is not nil
 }
 
 func newStdFilter(level int) *stdFilter {
-	return &stdFilter{
-		level: level,
+	f := &stdFilter{
+		level: driver.NewAtomicLevel(level),
 		enb:   true,
 		lo:    patt.NewLayout("").SetOptions("color", false),
 		out:   os.Stderr,
 	}
+	f.publish()
+	return f
+}
+
+// publish publishes the state after changing level, enb or out.
+func (f *stdFilter) publish() {
+	f.state.Store(stdState{level: f.level, on: f.enb && f.out != nil})
+	driver.InvalidateLevels()
+}
+
+// active returns true if the output writer is enabled and not nil.
+func (f *stdFilter) active() bool {
+	return f.state.Load().(stdState).on
 }
 
 func (f *stdFilter) setFlags(flag int) *stdFilter {
@@ -61,14 +86,22 @@ func (f *stdFilter) setFlags(flag int) *stdFilter {
 }
 
 func (f *stdFilter) enabled(level int) bool {
-	if f.enb && f.out != nil && level >= f.level {
-		return true
-	}
-	return false
+	st := f.state.Load().(stdState)
+	return st.on && !driver.LevelBelow(level, st.level.Level())
 }
 
-func (f *stdFilter) dispatch(r *driver.Recorder) {
-	buf := new(bytes.Buffer)
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func (f *stdFilter) dispatch(r *driver.Recorder) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+
 	f.lo.Encode(buf, r)
-	f.out.Write(buf.Bytes())
+	_, err := f.out.Write(buf.Bytes())
+	return err
 }
@@ -92,6 +92,12 @@ func levelToWriteFunc(e *Entry, level interface{}) func(interface{}, ...interfac
 	case CRITICAL:
 		return e.Critical
 	}
+	if _, ok := levels()[n]; ok {
+		// user-defined level
+		return func(arg0 interface{}, args ...interface{}) {
+			e.Log(2, n, arg0, args...)
+		}
+	}
 	return e.Info
 }
 
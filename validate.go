@@ -0,0 +1,224 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ccpaging/nxlog4go/cast"
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// Severity is the severity of a configuration diagnostic.
+type Severity int
+
+// Diagnostic severities.
+const (
+	SeverityWarning Severity = iota // the configuration can be loaded, but may not work as expected
+	SeverityError                   // the configuration is wrong
+)
+
+// String returns the lower name of the severity.
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic is a problem found in a logger configuration.
+type Diagnostic struct {
+	Severity Severity
+	Index    int    // the index of the filter, or -1
+	Tag      string // the tag of the filter, or the name of the named logger
+	Property string // the name of the property, "type", "dsn", "level", "processor", or ""
+	Message  string
+}
+
+// Error returns the diagnostic as text, such as
+//
+//	error: filter [1] file, property maxsize: ...
+func (d Diagnostic) Error() string {
+	s := d.Severity.String() + ": "
+	if d.Index >= 0 {
+		s += fmt.Sprintf("filter [%d] %s", d.Index, d.Tag)
+	} else if d.Tag != "" {
+		s += "logger " + d.Tag
+	} else {
+		s += "configuration"
+	}
+	if d.Property != "" {
+		s += ", property " + d.Property
+	}
+	return s + ": " + d.Message
+}
+
+// Diagnostics is a list of diagnostics.
+type Diagnostics []Diagnostic
+
+// HasError returns true if there is any diagnostic of SeverityError.
+func (ds Diagnostics) HasError() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConfiguration checks the configuration without loading it, and
+// returns diagnostics of:
+//   - invalid levels of filters and named loggers
+//   - unknown appender types
+//   - duplicate filter tags
+//   - unknown or ill-typed properties
+//   - unresolved variables and unreadable file references
+//   - unknown processor types and ill-typed processor properties
+//
+// Properties are checked by the registered appender if it implements
+// driver.Validator, without opening the appender. Otherwise, a warning is
+// reported that the properties are not validated.
+func ValidateConfiguration(lc *LoggerConfig) (ds Diagnostics) {
+	if lc == nil {
+		return append(ds, Diagnostic{Severity: SeverityError, Index: -1, Message: "configuration is NIL"})
+	}
+
+	tags := make(map[string]int)
+	for i, fc := range lc.Filters {
+		if fc == nil {
+			continue
+		}
+		ds = append(ds, validateFilter(i, fc, tags)...)
+	}
+
+	for _, err := range validateProcessors(lc.Processors) {
+		ds = append(ds, Diagnostic{Severity: SeverityError, Index: -1, Property: "processor", Message: err.Error()})
+	}
+
+	for _, nv := range lc.Loggers {
+		d := Diagnostic{Severity: SeverityError, Index: -1, Tag: nv.Name, Property: "level"}
+		if strings.Trim(nv.Name, ". ") == "" {
+			d.Message = "name of logger is not defined"
+			ds = append(ds, d)
+			continue
+		}
+		v, errs := expandValue(nv.Value)
+		for _, err := range errs {
+			ds = append(ds, Diagnostic{Severity: SeverityWarning, Index: -1, Tag: nv.Name, Property: "level", Message: err.Error()})
+		}
+		if v = strings.Trim(v, " \r\n"); v == "" {
+			continue
+		}
+		if _, ok := levelOf(v); !ok {
+			d.Message = fmt.Sprintf("invalid level %q", v)
+			ds = append(ds, d)
+		}
+	}
+	return
+}
+
+func validateFilter(i int, fc *FilterConfig, tags map[string]int) (ds Diagnostics) {
+	tag := fc.Tag
+	if tag == "" {
+		tag = fc.Type
+	}
+	report := func(sev Severity, prop string, format string, args ...interface{}) {
+		ds = append(ds, Diagnostic{Severity: sev, Index: i, Tag: tag, Property: prop, Message: fmt.Sprintf(format, args...)})
+	}
+	expand := func(prop string, v string) string {
+		v, errs := expandValue(v)
+		for _, err := range errs {
+			report(SeverityWarning, prop, "%v", err)
+		}
+		return strings.Trim(v, " \r\n")
+	}
+
+	if fc.Type == "" {
+		report(SeverityError, "type", "type is not defined")
+		return
+	}
+	if j, ok := tags[tag]; ok {
+		report(SeverityError, "", "duplicate tag of filter [%d]", j)
+	} else {
+		tags[tag] = i
+	}
+
+	if fc.Enabled != "" {
+		if _, err := cast.ToBool(fc.Enabled); err != nil {
+			report(SeverityWarning, "enabled", "not a bool, the filter is disabled. %v", err)
+		}
+	}
+	if level := expand("level", fc.Level); level != "" {
+		if _, ok := levelOf(level); !ok {
+			report(SeverityError, "level", "invalid level %q", level)
+		}
+	}
+	expand("dsn", fc.Dsn)
+
+	var set func(k string, v string) error
+	switch fc.Type {
+	case stdfName, "loglog":
+		l := NewLogger(INFO)
+		set = func(k string, v string) error { return l.Set(k, v) }
+	default:
+		if !driver.Registered(fc.Type) {
+			report(SeverityError, "type", "unknown appender type %q", fc.Type)
+			return
+		}
+
+		f := &driver.Filter{Enabler: driver.NewAtomicLevel(INFO)}
+		set = func(k string, v string) error {
+			if ok, err := setFilterOption(f, k, v); ok {
+				return err
+			}
+			return driver.Validate(fc.Type, k, v)
+		}
+	}
+
+	for _, err := range validateProcessors(fc.Processors) {
+		report(SeverityError, "processor", "%v", err)
+	}
+
+	skipped := false
+	for _, prop := range fc.Properties {
+		v := expand(prop.Name, prop.Value)
+		if prop.Name == "level" {
+			if _, ok := levelOf(v); !ok {
+				report(SeverityError, prop.Name, "invalid level %q", v)
+			}
+			continue
+		}
+		if err := set(prop.Name, v); err == driver.ErrNotValidated {
+			if !skipped {
+				report(SeverityWarning, "", "properties of appender type %q are not validated", fc.Type)
+				skipped = true
+			}
+		} else if err != nil {
+			report(SeverityError, prop.Name, "%v", err)
+		}
+	}
+	return
+}
+
+// validateProcessors opens the processors with expanded properties.
+func validateProcessors(pcs []*ProcessorConfig) (errs []error) {
+	for i, pc := range pcs {
+		if pc == nil {
+			continue
+		}
+		if pc.Type == "" {
+			errs = append(errs, fmt.Errorf("type of processor [%d] is not defined", i))
+			continue
+		}
+		var args []interface{}
+		for _, prop := range pc.Properties {
+			v, _ := expandValue(prop.Value)
+			args = append(args, prop.Name, strings.Trim(v, " \r\n"))
+		}
+		if _, err := driver.OpenProcessor(pc.Type, args...); err != nil {
+			errs = append(errs, fmt.Errorf("processor [%d] %s. %v", i, pc.Type, err))
+		}
+	}
+	return
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2017, ccpaging <ccpaging@gmail.com>.  All rights reserved.
+
+package nxlog4go_test
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+
+	l4g "github.com/ccpaging/nxlog4go"
+	"github.com/ccpaging/nxlog4go/driver"
+)
+
+// liveAppender fails the validation test if it is opened.
+type liveAppender struct {
+	driver.Appender
+	t *testing.T
+}
+
+func (a *liveAppender) Open(dsn string, args ...interface{}) (driver.Appender, error) {
+	a.t.Errorf("Validation should not open appenders")
+	return a, nil
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	lc := new(l4g.LoggerConfig)
+	if err := xml.Unmarshal([]byte(xmlBuf), lc); err != nil {
+		t.Fatalf("Could not parse XML configuration: %s", err)
+	}
+	if ds := l4g.ValidateConfiguration(lc); len(ds) != 0 {
+		t.Errorf("Unexpected diagnostics %v", ds)
+	}
+	if _, err := os.Stat("_test.log"); err == nil {
+		t.Errorf("Validation should not open files")
+	}
+
+	lc = &l4g.LoggerConfig{
+		Filters: []*l4g.FilterConfig{
+			{Tag: "a", Type: "console", Level: "LOUD", Properties: []l4g.NameValue{
+				{Name: "colour", Value: "true"},
+				{Name: "sampleFirst", Value: "many"},
+				{Name: "level", Value: "QUIET"},
+			}},
+			{Tag: "a", Type: "console", Level: "INFO"},
+			{Tag: "b", Type: "nosuch"},
+			{Tag: "c", Type: "file", Properties: []l4g.NameValue{
+				{Name: "maxsize", Value: "big"},
+				{Name: "format", Value: "${NXLOG4GO_UNSET}"},
+			}},
+		},
+		Loggers: []l4g.NameValue{{Name: "db", Value: "LOUD"}},
+	}
+
+	want := []struct {
+		sev   l4g.Severity
+		index int
+		prop  string
+	}{
+		{l4g.SeverityError, 0, "level"},
+		{l4g.SeverityError, 0, "colour"},
+		{l4g.SeverityError, 0, "sampleFirst"},
+		{l4g.SeverityError, 0, "level"},
+		{l4g.SeverityError, 1, ""},
+		{l4g.SeverityError, 2, "type"},
+		{l4g.SeverityError, 3, "maxsize"},
+		{l4g.SeverityWarning, 3, "format"},
+		{l4g.SeverityError, -1, "level"},
+	}
+	ds := l4g.ValidateConfiguration(lc)
+	if len(ds) != len(want) {
+		t.Fatalf("Expected %d diagnostics, found %d: %v", len(want), len(ds), ds)
+	}
+	for i, d := range ds {
+		if d.Severity != want[i].sev || d.Index != want[i].index || d.Property != want[i].prop {
+			t.Errorf("%d: unexpected %v", i, d)
+		}
+	}
+	if !ds.HasError() {
+		t.Errorf("Diagnostics should have errors")
+	}
+}
+
+func TestValidateConfigurationNotValidated(t *testing.T) {
+	driver.Register("live", &liveAppender{t: t})
+	defer driver.Register("live", nil)
+
+	ds := l4g.ValidateConfiguration(&l4g.LoggerConfig{
+		Filters: []*l4g.FilterConfig{
+			{Tag: "live", Type: "live", Dsn: "tcp://127.0.0.1:1", Properties: []l4g.NameValue{
+				{Name: "timeout", Value: "1s"},
+				{Name: "retry", Value: "3"},
+				{Name: "sampleFirst", Value: "many"},
+			}},
+		},
+	})
+	if len(ds) != 2 {
+		t.Fatalf("Expected 2 diagnostics, found %d: %v", len(ds), ds)
+	}
+	if d := ds[0]; d.Severity != l4g.SeverityWarning || d.Property != "" {
+		t.Errorf("Unexpected %v", d)
+	}
+	if d := ds[1]; d.Severity != l4g.SeverityError || d.Property != "sampleFirst" {
+		t.Errorf("Unexpected %v", d)
+	}
+}